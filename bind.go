@@ -0,0 +1,646 @@
+package gomonkey
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	valuerType   = reflect.TypeOf((*Valuer)(nil)).Elem()
+	valueType    = reflect.TypeOf((*Value)(nil))
+	objectType   = reflect.TypeOf((*Object)(nil))
+	functionType = reflect.TypeOf((*Function)(nil))
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	callInfoType = reflect.TypeOf((*CallInfo)(nil))
+	anyType      = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+var boundFieldsCache sync.Map
+
+// maxSliceLength is the maximum length of a JS array converted to a Go slice.
+const maxSliceLength = 1 << 24
+
+// Bind binds a Go struct to a new JS object and sets it as a property of the given JS object.
+//
+// The value must be a struct or a pointer to a struct. The exported fields are exposed as accessor properties and the
+// exported methods as functions, with their arguments and results converted between Go and JS values. A struct is
+// copied before binding, use a pointer to share it with JS.
+//
+// The fields are named after their Go name unless overridden by a `js:"name,omitempty,readonly"` tag. A field tagged
+// with "-" is not exposed, a field tagged with omitempty is undefined when it has its zero value, and a field tagged
+// with readonly has no setter. The fields holding JS values are always read-only. A method parameter of type *CallInfo
+// receives the information of the call instead of an argument. The methods return at most one value followed by an
+// optional error.
+func Bind(ctx *Context, object *Object, name string, value any) error {
+	rv := reflect.ValueOf(value)
+	if !isStruct(reflect.TypeOf(value)) {
+		return fmt.Errorf("cannot bind %T: not a struct", value)
+	}
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return fmt.Errorf("cannot bind %T: nil pointer", value)
+	}
+	bound, err := newBoundObject(ctx, rv)
+	if err != nil {
+		return err
+	}
+	defer bound.Release()
+	return ctx.DefineProperty(object, name, bound, PropertyAttributeDefault)
+}
+
+// boundField represents a Go struct field bound to a JS property.
+type boundField struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	readOnly  bool
+}
+
+// boundFields returns the bound fields of a Go struct type.
+func boundFields(t reflect.Type) []boundField {
+	if fields, ok := boundFieldsCache.Load(t); ok {
+		return fields.([]boundField)
+	}
+
+	var fields []boundField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || (f.Anonymous && isStruct(f.Type)) {
+			continue
+		}
+		tag := f.Tag.Get("js")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		field := boundField{
+			name:     name,
+			index:    f.Index,
+			typ:      f.Type,
+			readOnly: f.Type.Implements(valuerType),
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				field.omitEmpty = true
+			case "readonly":
+				field.readOnly = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	boundFieldsCache.Store(t, fields)
+	return fields
+}
+
+// isStruct checks if a Go type is a struct or a pointer to a struct.
+func isStruct(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// newBoundObject creates a new host object bound to a Go struct.
+func newBoundObject(ctx *Context, rv reflect.Value) (*Value, error) {
+	if rv.Kind() != reflect.Pointer {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr
+	}
+	object, err := NewHostObject(ctx, rv.Interface())
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.bindStruct(object, rv); err != nil {
+		object.Release()
+		return nil, err
+	}
+	return object.AsValue(), nil
+}
+
+// bindStruct defines the accessors and methods of a Go struct pointer on a JS object.
+func (c *Context) bindStruct(object *Object, ptr reflect.Value) error {
+	elem := ptr.Elem()
+	for _, field := range boundFields(elem.Type()) {
+		field := field
+		getter := func(info *CallInfo) (*Value, error) {
+			fv, err := elem.FieldByIndexErr(field.index)
+			if err != nil || (field.omitEmpty && fv.IsZero()) {
+				return nil, nil
+			}
+			if fv.Kind() == reflect.Struct {
+				return c.boundFieldObject(info.This, ptr, field.name, fv.Addr())
+			}
+			if fv.Kind() == reflect.Pointer && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct &&
+				!fv.Type().Implements(valuerType) {
+				return c.boundFieldObject(info.This, ptr, field.name, fv)
+			}
+			return toValue(c, fv)
+		}
+		var setter CallInfoCallback
+		if !field.readOnly {
+			setter = func(info *CallInfo) (*Value, error) {
+				fv, err := elem.FieldByIndexErr(field.index)
+				if err != nil {
+					return nil, fmt.Errorf("cannot set %s: %w", field.name, err)
+				}
+				var arg *Value
+				if len(info.Args) > 0 {
+					arg = info.Args[0]
+				}
+				rv, err := fromValue(arg, field.typ, nil)
+				if err != nil {
+					return nil, &typeError{fmt.Errorf("cannot set %s: %w", field.name, err)}
+				}
+				fv.Set(rv)
+				return nil, nil
+			}
+		}
+		if err := c.DefineAccessorWithCallInfo(object, field.name, getter, setter, PropertyAttributeDefault); err != nil {
+			return err
+		}
+	}
+
+	t := ptr.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		fn := ptr.Method(i)
+		callback, err := reflectCallback(c, fn)
+		if err != nil {
+			return fmt.Errorf("method %s: %w", method.Name, err)
+		}
+		if err := c.DefineFunctionWithCallInfo(object, method.Name, callback, reflectArgsCount(fn.Type()),
+			PropertyAttributeDefault); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boundFieldObject returns the host object bound to a struct field.
+//
+// The object is cached on the host object of the parent struct, so that reading the field twice returns the same
+// object as long as it points to the same Go struct.
+func (c *Context) boundFieldObject(this *Value, parent reflect.Value, name string, target reflect.Value) (*Value,
+	error) {
+	if this == nil || !this.IsObject() {
+		return newBoundObject(c, target)
+	}
+	object, _ := this.AsObject()
+	if data, err := object.HostData(); err != nil || data != parent.Interface() {
+		return newBoundObject(c, target)
+	}
+	cache, err := object.hostCache()
+	if err != nil {
+		return nil, err
+	}
+	defer cache.Release()
+
+	cached, err := cache.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if cached.IsObject() {
+		o, _ := cached.AsObject()
+		if data, err := o.HostData(); err == nil && data == target.Interface() {
+			return cached, nil
+		}
+	}
+	cached.Release()
+
+	bound, err := newBoundObject(c, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Set(name, bound); err != nil {
+		bound.Release()
+		return nil, err
+	}
+	return bound, nil
+}
+
+// reflectCallback returns a callback calling a Go function with the converted arguments.
+func reflectCallback(ctx *Context, fn reflect.Value) (CallInfoCallback, error) {
+	t := fn.Type()
+	if err := checkResults(t); err != nil {
+		return nil, err
+	}
+	return func(info *CallInfo) (*Value, error) {
+		var retained []*Value
+		defer func() {
+			for _, value := range retained {
+				value.Release()
+			}
+		}()
+		args, err := reflectArgs(info, t, &retained)
+		if err != nil {
+			return nil, err
+		}
+		return reflectResults(ctx, fn.Call(args))
+	}, nil
+}
+
+// checkResults checks that a Go function returns at most one value followed by an optional error.
+func checkResults(t reflect.Type) error {
+	n := t.NumOut()
+	if n > 0 && t.Out(n-1) == errorType {
+		n--
+	}
+	if n > 1 {
+		return fmt.Errorf("cannot convert the %d results of %s", n, t)
+	}
+	if n == 1 && t.Out(0) == errorType {
+		return fmt.Errorf("cannot convert the results of %s: the error is not last", t)
+	}
+	return nil
+}
+
+// reflectArgsCount returns the number of JS arguments expected by a Go function.
+func reflectArgsCount(t reflect.Type) uint {
+	var n uint
+	for i := 0; i < t.NumIn(); i++ {
+		if t.In(i) != callInfoType && !(t.IsVariadic() && i == t.NumIn()-1) {
+			n++
+		}
+	}
+	return n
+}
+
+// reflectArgs converts the JS arguments of a call to the parameters of a Go function. The JS values nested in the
+// arguments are retained until released by the caller.
+func reflectArgs(info *CallInfo, t reflect.Type, retained *[]*Value) ([]reflect.Value, error) {
+	values := info.Args
+	args := make([]reflect.Value, 0, t.NumIn())
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if in == callInfoType {
+			args = append(args, reflect.ValueOf(info))
+			continue
+		}
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			for j, value := range values {
+				arg, err := fromValue(value, in.Elem(), retained)
+				if err != nil {
+					return nil, &typeError{fmt.Errorf("argument %d: %w", len(args)+j+1, err)}
+				}
+				args = append(args, arg)
+			}
+			break
+		}
+		var value *Value
+		if len(values) > 0 {
+			value, values = values[0], values[1:]
+		}
+		arg, err := fromValue(value, in, retained)
+		if err != nil {
+			return nil, &typeError{fmt.Errorf("argument %d: %w", len(args)+1, err)}
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// reflectResults converts the results of a Go function call to a JS value.
+func reflectResults(ctx *Context, results []reflect.Value) (*Value, error) {
+	if n := len(results); n > 0 && results[n-1].Type() == errorType {
+		if !results[n-1].IsNil() {
+			return nil, results[n-1].Interface().(error)
+		}
+		results = results[:n-1]
+	}
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return toValue(ctx, results[0])
+	default:
+		return nil, fmt.Errorf("cannot convert %d results", len(results))
+	}
+}
+
+// typeError represents a conversion error thrown as a JS TypeError.
+type typeError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *typeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the conversion error.
+func (e *typeError) Unwrap() error {
+	return e.err
+}
+
+// toValue converts a Go value to a new JS value.
+func toValue(ctx *Context, rv reflect.Value) (*Value, error) {
+	if !rv.IsValid() {
+		return NewValueUndefined(ctx)
+	}
+	if rv.Type().Implements(valuerType) {
+		if (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && rv.IsNil() {
+			return NewValueNull(ctx)
+		}
+		return rv.Interface().(Valuer).AsValue().clone()
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return NewValueBoolean(ctx, rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewValueNumber(ctx, float64(rv.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewValueNumber(ctx, float64(rv.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return NewValueNumber(ctx, rv.Float())
+	case reflect.String:
+		return NewValueString(ctx, rv.String())
+	case reflect.Interface:
+		if rv.IsNil() {
+			return NewValueNull(ctx)
+		}
+		return toValue(ctx, rv.Elem())
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return NewValueNull(ctx)
+		}
+		if rv.Elem().Kind() == reflect.Struct {
+			return newBoundObject(ctx, rv)
+		}
+		return toValue(ctx, rv.Elem())
+	case reflect.Struct:
+		return newBoundObject(ctx, rv)
+	case reflect.Slice:
+		if rv.IsNil() {
+			return NewValueNull(ctx)
+		}
+		fallthrough
+	case reflect.Array:
+		values := make([]*Value, 0, rv.Len())
+		defer func() {
+			for _, value := range values {
+				value.Release()
+			}
+		}()
+		for i := 0; i < rv.Len(); i++ {
+			value, err := toValue(ctx, rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		array, err := NewArrayObject(ctx, values...)
+		if err != nil {
+			return nil, err
+		}
+		return array.AsValue(), nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return NewValueNull(ctx)
+		}
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot convert %s: map key is not a string", rv.Type())
+		}
+		object, err := NewObject(ctx)
+		if err != nil {
+			return nil, err
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			value, err := toValue(ctx, iter.Value())
+			if err != nil {
+				object.Release()
+				return nil, err
+			}
+			err = object.Set(iter.Key().String(), value)
+			value.Release()
+			if err != nil {
+				object.Release()
+				return nil, err
+			}
+		}
+		return object.AsValue(), nil
+	case reflect.Func:
+		if rv.IsNil() {
+			return NewValueNull(ctx)
+		}
+		callback, err := reflectCallback(ctx, rv)
+		if err != nil {
+			return nil, err
+		}
+		function, err := NewFunctionWithCallInfo(ctx, "", callback)
+		if err != nil {
+			return nil, err
+		}
+		return function.AsValue(), nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a JS value", rv.Type())
+	}
+}
+
+// fromValue converts a JS value to a Go value of the given type.
+//
+// A nil value is converted as undefined. The JS values of the conversion are only valid during the call. The JS values
+// of the elements and fields are appended to the retained values, or rejected if they are nil.
+func fromValue(v *Value, t reflect.Type, retained *[]*Value) (reflect.Value, error) {
+	if v == nil || v.IsUndefined() || (v.IsNull() && isNullable(t)) {
+		return reflect.Zero(t), nil
+	}
+
+	switch t {
+	case valueType:
+		return reflect.ValueOf(v), nil
+	case objectType:
+		object, err := v.AsObject()
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", v.typeOf(), t)
+		}
+		return reflect.ValueOf(object), nil
+	case functionType:
+		function, err := v.AsFunction()
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", v.typeOf(), t)
+		}
+		return reflect.ValueOf(function), nil
+	}
+	if isStruct(t) && v.IsObject() {
+		object, _ := v.AsObject()
+		if data, err := object.HostData(); err == nil {
+			rv := reflect.ValueOf(data)
+			if rv.Type() == t {
+				return rv, nil
+			}
+			if rv.Kind() == reflect.Pointer && rv.Type().Elem() == t {
+				return rv.Elem(), nil
+			}
+		}
+	}
+
+	rv := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		if !v.IsBoolean() {
+			break
+		}
+		rv.SetBool(v.ToBoolean())
+		return rv, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !v.IsNumber() {
+			break
+		}
+		n := v.ToNumber()
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 || rv.OverflowInt(int64(n)) {
+			return reflect.Value{}, fmt.Errorf("cannot convert %v to %s", n, t)
+		}
+		rv.SetInt(int64(n))
+		return rv, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !v.IsNumber() {
+			break
+		}
+		n := v.ToNumber()
+		if n != math.Trunc(n) || n < 0 || n >= math.MaxUint64 || rv.OverflowUint(uint64(n)) {
+			return reflect.Value{}, fmt.Errorf("cannot convert %v to %s", n, t)
+		}
+		rv.SetUint(uint64(n))
+		return rv, nil
+	case reflect.Float32, reflect.Float64:
+		if !v.IsNumber() {
+			break
+		}
+		rv.SetFloat(v.ToNumber())
+		return rv, nil
+	case reflect.String:
+		if !v.IsString() {
+			break
+		}
+		rv.SetString(v.ToString())
+		return rv, nil
+	case reflect.Pointer:
+		elem, err := fromValue(v, t.Elem(), retained)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		rv.Set(reflect.New(t.Elem()))
+		rv.Elem().Set(elem)
+		return rv, nil
+	case reflect.Struct:
+		if !v.IsObject() || v.IsFunction() {
+			break
+		}
+		object, _ := v.AsObject()
+		for _, field := range boundFields(t) {
+			value, err := object.Get(field.name)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			fv, err := fromValue(value, field.typ, retained)
+			if err == nil {
+				err = retainValue(value, field.typ, retained)
+			}
+			if err == nil {
+				var target reflect.Value
+				target, err = rv.FieldByIndexErr(field.index)
+				if err == nil {
+					target.Set(fv)
+				}
+			}
+			if err != nil || !field.typ.Implements(valuerType) {
+				value.Release()
+			}
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("field %s: %w", field.name, err)
+			}
+		}
+		return rv, nil
+	case reflect.Slice, reflect.Array:
+		if !v.IsObject() || v.IsFunction() {
+			break
+		}
+		object, _ := v.AsObject()
+		length, err := object.Get("length")
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		f := math.NaN()
+		if length.IsNumber() {
+			f = length.ToNumber()
+		}
+		length.Release()
+		if f != math.Trunc(f) || f < 0 || f > maxSliceLength {
+			return reflect.Value{}, fmt.Errorf("cannot convert %s to %s: invalid length", v.typeOf(), t)
+		}
+		n := int(f)
+		if t.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(t, n, n))
+		}
+		for i := 0; i < n && i < rv.Len(); i++ {
+			value, err := object.GetElement(i)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elem, err := fromValue(value, t.Elem(), retained)
+			if err == nil {
+				err = retainValue(value, t.Elem(), retained)
+			}
+			if err == nil {
+				rv.Index(i).Set(elem)
+			}
+			if err != nil || !t.Elem().Implements(valuerType) {
+				value.Release()
+			}
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return rv, nil
+	case reflect.Map, reflect.Interface:
+		if t.Kind() == reflect.Interface && t != anyType {
+			break
+		}
+		if v.IsFunction() || v.IsSymbol() {
+			break
+		}
+		data, err := JSONStringify(v.ctx, v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if err := json.Unmarshal([]byte(data), rv.Addr().Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot convert %s to %s: %w", v.typeOf(), t, err)
+		}
+		return rv, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", v.typeOf(), t)
+}
+
+// retainValue appends a JS value converted to a JS value type to the retained values.
+func retainValue(v *Value, t reflect.Type, retained *[]*Value) error {
+	if !t.Implements(valuerType) {
+		return nil
+	}
+	if retained == nil {
+		return fmt.Errorf("cannot convert %s to %s: JS values are only retained during a call", v.typeOf(), t)
+	}
+	*retained = append(*retained, v)
+	return nil
+}
+
+// isNullable checks if a Go type accepts a JS null value as its zero value.
+func isNullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package gomonkey
+
+// #include "gomonkey.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// ClassSpec represents the specification of a JS class.
+type ClassSpec struct {
+	// Name is the name of the class.
+	Name string
+	// Constructor returns the Go value of a new instance, retrieved with Object.HostData.
+	Constructor ConstructorCallback
+	// Args is the number of arguments of the constructor.
+	Args uint
+	// Methods are the methods of the class prototype.
+	Methods map[string]CallInfoCallback
+	// StaticMethods are the methods of the class constructor.
+	StaticMethods map[string]CallInfoCallback
+	// Accessors are the accessor properties of the class prototype.
+	Accessors map[string]AccessorSpec
+}
+
+// AccessorSpec represents the specification of an accessor property.
+type AccessorSpec struct {
+	Get CallInfoCallback
+	Set CallInfoCallback
+}
+
+// ConstructorCallback implements a JS class constructor callback returning the Go value of the new instance.
+type ConstructorCallback func(info *CallInfo) (any, error)
+
+// DefineClass defines a new JS class and sets its constructor as a property of the given JS object.
+func (c *Context) DefineClass(object *Object, spec ClassSpec) (*Function, error) {
+	handle := c.registerCallback(func(info *CallInfo) (*Value, error) {
+		if info.NewTarget.IsUndefined() {
+			return nil, &CallbackError{
+				Name:    "TypeError",
+				Message: "class constructor " + spec.Name + " cannot be invoked without 'new'",
+			}
+		}
+		var data any
+		if spec.Constructor != nil {
+			var err error
+			data, err = spec.Constructor(info)
+			if err != nil {
+				return nil, err
+			}
+		}
+		handle := c.registerHostData(data)
+		if !C.SetHostObjectData(info.This.ptr, C.uint(handle)) {
+			c.unregisterHostData(handle)
+			return nil, errors.New("set host object data")
+		}
+		return nil, nil
+	})
+	cName := C.CString(spec.Name)
+	result := C.DefineClass(c.ptr, object.AsValue().ptr, cName, C.uint(handle), C.uint(spec.Args), 0)
+	C.free(unsafe.Pointer(cName))
+	if !result.ok {
+		c.unregisterCallback(handle)
+		return nil, newJSError(result.err)
+	}
+	constructor := &Function{&Value{result.ptr, c}}
+
+	if err := c.defineClassMembers(constructor, spec); err != nil {
+		constructor.Release()
+		return nil, err
+	}
+	return constructor, nil
+}
+
+// defineClassMembers defines the methods and accessors of a JS class.
+func (c *Context) defineClassMembers(constructor *Function, spec ClassSpec) error {
+	static := &Object{constructor.v}
+	for name, method := range spec.StaticMethods {
+		if err := c.DefineFunctionWithCallInfo(static, name, method, 0, PropertyAttributeDefault); err != nil {
+			return err
+		}
+	}
+
+	value, err := static.Get("prototype")
+	if err != nil {
+		return err
+	}
+	defer value.Release()
+	prototype, err := value.AsObject()
+	if err != nil {
+		return err
+	}
+	for name, method := range spec.Methods {
+		if err := c.DefineFunctionWithCallInfo(prototype, name, method, 0, PropertyAttributeDefault); err != nil {
+			return err
+		}
+	}
+	for name, accessor := range spec.Accessors {
+		if err := c.DefineAccessorWithCallInfo(prototype, name, accessor.Get, accessor.Set, PropertyAttributeDefault); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -21,6 +21,11 @@ func main() {
 	_ = createGlobalFunction()
 	_ = createFunctionObject()
 	_ = createObjectMethod()
+	_ = resolvePromise()
+	_ = executeModule()
+	_ = defineClass()
+	_ = bindStruct()
+	_ = mapSourceErrors()
 }
 
 func contexts() error {
@@ -460,3 +465,303 @@ func createObjectMethod() error {
 
 	return nil
 }
+
+func resolvePromise() error {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		runtime.LockOSThread()
+		defer func() {
+			runtime.UnlockOSThread()
+			wg.Done()
+		}()
+
+		ctx, err := gomonkey.NewContext()
+		if err != nil {
+			return
+		}
+		defer ctx.Destroy()
+
+		// evaluate some async code ...
+
+		value, err := ctx.Evaluate([]byte("(async () => { return 'result'; })()"))
+		if err != nil {
+			return
+		}
+		defer value.Release() // release after usage
+
+		promise, err := value.AsPromise()
+		if err != nil {
+			return
+		}
+
+		// ... run the pending jobs ...
+
+		if err := ctx.RunJobs(); err != nil {
+			return
+		}
+
+		// ... and get the promise result
+
+		if state, err := promise.State(); err != nil || state != gomonkey.PromiseStateFulfilled { // check promise state
+			return
+		}
+		result, err := promise.Result()
+		if err != nil {
+			return
+		}
+		defer result.Release() // release after usage
+	}()
+
+	wg.Wait()
+
+	return nil
+}
+
+func executeModule() error {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		runtime.LockOSThread()
+		defer func() {
+			runtime.UnlockOSThread()
+			wg.Done()
+		}()
+
+		// create a module loader from a file system ...
+
+		loader, err := gomonkey.NewFSModuleLoader(os.DirFS("."),
+			gomonkey.WithImportMap([]byte(`{"imports": {"lib": "/script.js"}}`)))
+		if err != nil {
+			return
+		}
+
+		// ... and create the context with it ...
+
+		ctx, err := gomonkey.NewContext(gomonkey.WithModuleLoader(loader))
+		if err != nil {
+			return
+		}
+		defer ctx.Destroy()
+
+		// ... compile your module ...
+
+		module, err := ctx.CompileModule("main.js", []byte("import './script.js'; export default 'result';"))
+		if err != nil {
+			return
+		}
+		defer module.Release() // release after usage
+
+		// ... link its imported modules ...
+
+		if err := module.Link(); err != nil {
+			return
+		}
+
+		// ... evaluate it ...
+
+		promise, err := module.Evaluate()
+		if err != nil {
+			return
+		}
+		defer promise.Release() // release after usage
+
+		if err := ctx.RunJobs(); err != nil { // settle any top-level await
+			return
+		}
+
+		// ... and use its exports
+
+		ns, err := module.Namespace()
+		if err != nil {
+			return
+		}
+		defer ns.Release() // release after usage
+
+		result, err := ns.Get("default")
+		if err != nil {
+			return
+		}
+		defer result.Release() // release after usage
+	}()
+
+	wg.Wait()
+
+	return nil
+}
+
+func defineClass() error {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		runtime.LockOSThread()
+		defer func() {
+			runtime.UnlockOSThread()
+			wg.Done()
+		}()
+
+		ctx, err := gomonkey.NewContext()
+		if err != nil {
+			return
+		}
+		defer ctx.Destroy()
+
+		global, err := ctx.Global()
+		if err != nil {
+			return
+		}
+		defer global.Release()
+
+		// define a class with a Go value for each instance ...
+
+		type counter struct{ value int32 }
+
+		constructor, err := ctx.DefineClass(global, gomonkey.ClassSpec{
+			Name: "Counter",
+			Constructor: func(info *gomonkey.CallInfo) (any, error) {
+				return &counter{}, nil // returns the Go value of the new instance
+			},
+			Methods: map[string]gomonkey.CallInfoCallback{
+				"increment": func(info *gomonkey.CallInfo) (*gomonkey.Value, error) {
+					this, err := info.This.AsObject() // do not release it
+					if err != nil {
+						return nil, err
+					}
+					data, err := this.HostData() // retrieve the Go value of the instance
+					if err != nil {
+						return nil, err
+					}
+					c := data.(*counter)
+					c.value++
+					return gomonkey.NewValueInt32(ctx, c.value) // do not release it
+				},
+			},
+		})
+		if err != nil {
+			return
+		}
+		defer constructor.Release() // release after usage
+
+		// ... and use it from JS
+
+		result, err := ctx.Evaluate([]byte("const c = new Counter(); c.increment(); c.increment();"))
+		if err != nil {
+			return
+		}
+		defer result.Release() // release after usage
+	}()
+
+	wg.Wait()
+
+	return nil
+}
+
+// account is the Go struct bound in the bindStruct example.
+type account struct {
+	Owner   string  `js:"owner"`
+	Balance float64 `js:"balance,readonly"`
+}
+
+// Deposit adds an amount to the account balance.
+func (a *account) Deposit(amount float64) (float64, error) {
+	if amount <= 0 {
+		return 0, errors.New("invalid amount")
+	}
+	a.Balance += amount
+	return a.Balance, nil
+}
+
+func bindStruct() error {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		runtime.LockOSThread()
+		defer func() {
+			runtime.UnlockOSThread()
+			wg.Done()
+		}()
+
+		ctx, err := gomonkey.NewContext()
+		if err != nil {
+			return
+		}
+		defer ctx.Destroy()
+
+		global, err := ctx.Global()
+		if err != nil {
+			return
+		}
+		defer global.Release()
+
+		// bind a Go struct ...
+
+		acc := &account{Owner: "John"}
+		if err := gomonkey.Bind(ctx, global, "account", acc); err != nil {
+			return
+		}
+
+		// ... and use it from JS
+
+		result, err := ctx.Evaluate([]byte("account.Deposit(50); account.Deposit(25); account.balance;"))
+		if err != nil {
+			return
+		}
+		defer result.Release() // release after usage
+	}()
+
+	wg.Wait()
+
+	return nil
+}
+
+func mapSourceErrors() error {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		runtime.LockOSThread()
+		defer func() {
+			runtime.UnlockOSThread()
+			wg.Done()
+		}()
+
+		ctx, err := gomonkey.NewContext()
+		if err != nil {
+			return
+		}
+		defer ctx.Destroy()
+
+		// register the source map of a bundled script ...
+
+		m, err := gomonkey.ParseSourceMap([]byte(`{"version":3,"sources":["app.js"],"names":[],"mappings":"AAAA,gBACE,MAAM;AAER"}`))
+		if err != nil {
+			return
+		}
+		ctx.RegisterSourceMap("bundle.js", m)
+
+		script, err := ctx.CompileScript("bundle.js", []byte("function fail(){throw new Error(\"boom\")}\nfail();"))
+		if err != nil {
+			return
+		}
+		defer script.Release() // release after usage
+
+		// ... and get its errors at their original positions
+
+		_, err = ctx.ExecuteScript(script)
+		var jsErr *gomonkey.JSError
+		if !errors.As(err, &jsErr) {
+			return
+		}
+		defer jsErr.Release() // release after usage
+		_ = jsErr.Filename    // app.js
+		_ = jsErr.LineNumber  // 2
+	}()
+
+	wg.Wait()
+
+	return nil
+}
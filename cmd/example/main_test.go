@@ -61,3 +61,33 @@ func TestCreateObjectMethod(t *testing.T) {
 		t.Errorf("invalid code, got error: %s", err)
 	}
 }
+
+func TestResolvePromise(t *testing.T) {
+	if err := resolvePromise(); err != nil {
+		t.Errorf("invalid code, got error: %s", err)
+	}
+}
+
+func TestExecuteModule(t *testing.T) {
+	if err := executeModule(); err != nil {
+		t.Errorf("invalid code, got error: %s", err)
+	}
+}
+
+func TestDefineClass(t *testing.T) {
+	if err := defineClass(); err != nil {
+		t.Errorf("invalid code, got error: %s", err)
+	}
+}
+
+func TestBindStruct(t *testing.T) {
+	if err := bindStruct(); err != nil {
+		t.Errorf("invalid code, got error: %s", err)
+	}
+}
+
+func TestMapSourceErrors(t *testing.T) {
+	if err := mapSourceErrors(); err != nil {
+		t.Errorf("invalid code, got error: %s", err)
+	}
+}
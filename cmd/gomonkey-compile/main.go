@@ -0,0 +1,265 @@
+// This command compiles JS files into stencils embedded in a generated Go file.
+//
+// It is intended to be used with go:generate:
+//
+//	//go:generate go run github.com/bhuisgen/gomonkey/cmd/gomonkey-compile -dir js -out stencils_gen.go
+//
+// The generated file exposes the function LoadStencils() (map[string]*gomonkey.Stencil, error) returning the
+// stencils indexed by the slash-separated paths of the JS files relative to the source directory. The source maps
+// referenced by the JS files are embedded with their stencils.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+// stencilExt is the file extension of the stencil blobs.
+const stencilExt = ".stencil"
+
+// options represents the command options.
+type options struct {
+	dir     string
+	out     string
+	blobs   string
+	pkg     string
+	modules bool
+}
+
+// source represents a compiled source file.
+type source struct {
+	Name string
+	Blob string
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.dir, "dir", ".", "directory of the JS files")
+	flag.StringVar(&opts.out, "out", "stencils_gen.go", "generated Go file")
+	flag.StringVar(&opts.blobs, "blobs", "stencils", "directory of the stencil blobs, relative to the generated file")
+	flag.StringVar(&opts.pkg, "pkg", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	flag.BoolVar(&opts.modules, "modules", false, "compile the .js files as modules")
+	flag.Usage = func() {
+		fmt.Println("Usage: gomonkey-compile [OPTIONS]")
+		fmt.Println()
+		fmt.Println("Compile the JS files of a directory into stencils embedded in a generated Go file.")
+		fmt.Println()
+		fmt.Println("The .mjs files are always compiled as modules.")
+		fmt.Println()
+		fmt.Println("Options:")
+		flag.PrintDefaults()
+		fmt.Println()
+	}
+
+	flag.Parse()
+
+	if opts.pkg == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	gomonkey.Init()
+	err := run(opts)
+	gomonkey.ShutDown()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run compiles the JS files and generates the Go file.
+func run(opts options) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	blobs := path.Clean(filepath.ToSlash(opts.blobs))
+	if blobs == "." || path.IsAbs(blobs) || blobs == ".." || strings.HasPrefix(blobs, "../") {
+		return fmt.Errorf("invalid blobs directory %q", opts.blobs)
+	}
+	blobsDir := filepath.Join(filepath.Dir(opts.out), filepath.FromSlash(blobs))
+	overlap, err := contains(blobsDir, opts.dir)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		return fmt.Errorf("blobs directory %q contains the source directory %q", blobsDir, opts.dir)
+	}
+	if err := removeStencils(blobsDir); err != nil {
+		return err
+	}
+
+	fsys := os.DirFS(opts.dir)
+	ctx, err := gomonkey.NewFrontendContext(gomonkey.WithFrontendSourceMaps(fsys))
+	if err != nil {
+		return err
+	}
+	defer ctx.Destroy()
+
+	var sources []source
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		ext := path.Ext(name)
+		if ext != ".js" && ext != ".mjs" {
+			return nil
+		}
+
+		code, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		module := ext == ".mjs" || opts.modules
+		var stencil *gomonkey.Stencil
+		if module {
+			stencil, err = ctx.CompileModuleToStencil(name, code)
+		} else {
+			stencil, err = ctx.CompileScriptToStencil(name, code)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		data, err := stencil.MarshalBinary()
+		stencil.Release()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		blob := path.Join(blobs, name+stencilExt)
+		file := filepath.Join(filepath.Dir(opts.out), filepath.FromSlash(blob))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			return err
+		}
+		sources = append(sources, source{Name: name, Blob: blob})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return errors.New("no JS files found")
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Name < sources[j].Name
+	})
+
+	code, err := generate(opts.pkg, blobs, sources)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(opts.out, code, 0o644)
+}
+
+// contains checks if the parent directory is or contains the directory.
+func contains(parent string, dir string) (bool, error) {
+	absParent, err := filepath.Abs(parent)
+	if err != nil {
+		return false, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(absParent, absDir)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+// removeStencils removes the stencil blobs previously generated in the directory.
+func removeStencils(dir string) error {
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && strings.HasSuffix(name, stencilExt) {
+			return os.Remove(name)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// generate generates the Go file embedding the stencil blobs.
+func generate(pkg string, blobs string, sources []source) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Package string
+		Blobs   string
+		Sources []source
+	}{
+		Package: pkg,
+		Blobs:   blobs,
+		Sources: sources,
+	}); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+var tmpl = template.Must(template.New("").Parse(`// Code generated by gomonkey-compile; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"embed"
+	"fmt"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+//go:embed all:{{ .Blobs }}
+var stencilBlobs embed.FS
+
+// stencilFiles maps the JS files to their stencil blobs.
+var stencilFiles = map[string]string{
+{{- range .Sources }}
+	{{ printf "%q" .Name }}: {{ printf "%q" .Blob }},
+{{- end }}
+}
+
+// LoadStencils loads the embedded stencils indexed by the paths of their JS files.
+//
+// The stencils must be released after usage.
+func LoadStencils() (map[string]*gomonkey.Stencil, error) {
+	stencils := make(map[string]*gomonkey.Stencil, len(stencilFiles))
+	for name, blob := range stencilFiles {
+		var stencil *gomonkey.Stencil
+		data, err := stencilBlobs.ReadFile(blob)
+		if err == nil {
+			stencil, err = gomonkey.UnmarshalStencil(data)
+		}
+		if err != nil {
+			for _, stencil := range stencils {
+				stencil.Release()
+			}
+			return nil, fmt.Errorf("load stencil %s: %w", name, err)
+		}
+		stencils[name] = stencil
+	}
+	return stencils, nil
+}
+`))
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+func TestRun(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "lib"), 0o755); err != nil {
+		t.Fatal()
+	}
+	if err := os.WriteFile(filepath.Join(src, "script.js"), []byte(`(() => { return "test"; })()`), 0o644); err != nil {
+		t.Fatal()
+	}
+	if err := os.WriteFile(filepath.Join(src, "lib", "module.mjs"), []byte(`export const test = "test";`), 0o644); err != nil {
+		t.Fatal()
+	}
+	if err := os.WriteFile(filepath.Join(src, "_helpers.js"), []byte(`var helper = true;`), 0o644); err != nil {
+		t.Fatal()
+	}
+	out := filepath.Join(t.TempDir(), "stencils_gen.go")
+
+	err := run(options{
+		dir:   src,
+		out:   out,
+		blobs: "stencils",
+		pkg:   "test",
+	})
+	if err != nil {
+		t.Fatalf("run() err = %v, want %v", err, nil)
+	}
+
+	code, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal()
+	}
+	for _, s := range []string{
+		"package test",
+		"func LoadStencils() (map[string]*gomonkey.Stencil, error)",
+		"//go:embed all:stencils",
+		`"_helpers.js":    "stencils/_helpers.js.stencil"`,
+		`"lib/module.mjs": "stencils/lib/module.mjs.stencil"`,
+		`"script.js":      "stencils/script.js.stencil"`,
+	} {
+		if !strings.Contains(string(code), s) {
+			t.Errorf("run() code does not contain %q", s)
+		}
+	}
+
+	for _, blob := range []string{"_helpers.js.stencil", "script.js.stencil", filepath.Join("lib", "module.mjs.stencil")} {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(out), "stencils", blob))
+		if err != nil {
+			t.Fatalf("run() blob %s err = %v, want %v", blob, err, nil)
+		}
+		stencil, err := gomonkey.UnmarshalStencil(data)
+		if err != nil {
+			t.Errorf("gomonkey.UnmarshalStencil() err = %v, want %v", err, nil)
+			continue
+		}
+		stencil.Release()
+	}
+}
+
+func TestRun_Build(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "_helpers.js"), []byte(`var helper = true;`), 0o644); err != nil {
+		t.Fatal()
+	}
+	// the generated package is built inside the module to resolve the gomonkey package
+	dir, err := os.MkdirTemp(".", "_generated")
+	if err != nil {
+		t.Fatal()
+	}
+	defer os.RemoveAll(dir)
+
+	err = run(options{
+		dir:   src,
+		out:   filepath.Join(dir, "stencils_gen.go"),
+		blobs: "stencils",
+		pkg:   "generated",
+	})
+	if err != nil {
+		t.Fatalf("run() err = %v, want %v", err, nil)
+	}
+
+	cmd := exec.Command(goBin, "build", "./"+filepath.ToSlash(dir))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go build err = %v, want %v: %s", err, nil, output)
+	}
+}
+
+func TestRun_BlobsOverlap(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "js", "lib"), 0o755); err != nil {
+		t.Fatal()
+	}
+	script := filepath.Join(root, "js", "lib", "script.js")
+	if err := os.WriteFile(script, []byte(`(() => { return "test"; })()`), 0o644); err != nil {
+		t.Fatal()
+	}
+
+	for _, dir := range []string{filepath.Join(root, "js"), filepath.Join(root, "js", "lib")} {
+		err := run(options{
+			dir:   dir,
+			out:   filepath.Join(root, "stencils_gen.go"),
+			blobs: "js",
+			pkg:   "test",
+		})
+		if err == nil {
+			t.Errorf("run() err = %v, want error", err)
+		}
+	}
+
+	// the blobs directory may be inside the source directory
+	err := run(options{
+		dir:   root,
+		out:   filepath.Join(root, "stencils_gen.go"),
+		blobs: "stencils",
+		pkg:   "test",
+	})
+	if err != nil {
+		t.Errorf("run() err = %v, want %v", err, nil)
+	}
+	if _, err := os.Stat(script); err != nil {
+		t.Errorf("os.Stat() err = %v, want %v", err, nil)
+	}
+}
+
+func TestRun_NoFiles(t *testing.T) {
+	err := run(options{
+		dir:   t.TempDir(),
+		out:   filepath.Join(t.TempDir(), "stencils_gen.go"),
+		blobs: "stencils",
+		pkg:   "test",
+	})
+	if err == nil {
+		t.Errorf("run() err = %v, want error", err)
+	}
+}
+
+func TestRun_SyntaxError(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "script.js"), []byte(`(() => {`), 0o644); err != nil {
+		t.Fatal()
+	}
+
+	err := run(options{
+		dir:   src,
+		out:   filepath.Join(t.TempDir(), "stencils_gen.go"),
+		blobs: "stencils",
+		pkg:   "test",
+	})
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("run() err = %v, want *gomonkey.JSError", err)
+	}
+	if jsErr.Filename != "script.js" || jsErr.LineNumber != 1 {
+		t.Errorf("run() err location = %s:%d, want %s:%d", jsErr.Filename, jsErr.LineNumber, "script.js", 1)
+	}
+}
+
+const sourceMap = `{"version":3,"sources":["app.js"],"sourceRoot":"src","names":[],"mappings":"AAAA,gBACE,MAAM;AAER"}`
+
+func TestRun_SourceMap(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	src := t.TempDir()
+	code := "function fail(){throw new Error(\"boom\")}\nfail();\n//# sourceMappingURL=bundle.js.map\n"
+	if err := os.WriteFile(filepath.Join(src, "bundle.js"), []byte(code), 0o644); err != nil {
+		t.Fatal()
+	}
+	if err := os.WriteFile(filepath.Join(src, "bundle.js.map"), []byte(sourceMap), 0o644); err != nil {
+		t.Fatal()
+	}
+	out := filepath.Join(t.TempDir(), "stencils_gen.go")
+
+	if err := run(options{dir: src, out: out, blobs: "stencils", pkg: "test"}); err != nil {
+		t.Fatalf("run() err = %v, want %v", err, nil)
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(out), "stencils", "bundle.js.stencil"))
+	if err != nil {
+		t.Fatal()
+	}
+	stencil, err := gomonkey.UnmarshalStencil(data)
+	if err != nil {
+		t.Fatalf("gomonkey.UnmarshalStencil() err = %v, want %v", err, nil)
+	}
+	defer stencil.Release()
+	if stencil.SourceMap() == nil {
+		t.Fatalf("stencil.SourceMap() = %v, want a source map", stencil.SourceMap())
+	}
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	value, err := ctx.ExecuteScriptFromStencil(stencil)
+	if err == nil {
+		value.Release()
+		t.Fatalf("ctx.ExecuteScriptFromStencil() err = %v, want an error", err)
+	}
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("ctx.ExecuteScriptFromStencil() err = %v, want *gomonkey.JSError", err)
+	}
+	if jsErr.Filename != "src/app.js" || jsErr.LineNumber != 2 {
+		t.Errorf("jsErr location = %s:%d, want %s:%d", jsErr.Filename, jsErr.LineNumber, "src/app.js", 2)
+	}
+}
+
+func TestRun_SourceMapSyntaxError(t *testing.T) {
+	src := t.TempDir()
+	code := "function fail(){throw new Error(\"boom\")}\nfail(;\n//# sourceMappingURL=bundle.js.map\n"
+	if err := os.WriteFile(filepath.Join(src, "bundle.js"), []byte(code), 0o644); err != nil {
+		t.Fatal()
+	}
+	if err := os.WriteFile(filepath.Join(src, "bundle.js.map"), []byte(sourceMap), 0o644); err != nil {
+		t.Fatal()
+	}
+
+	err := run(options{dir: src, out: filepath.Join(t.TempDir(), "stencils_gen.go"), blobs: "stencils", pkg: "test"})
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("run() err = %v, want *gomonkey.JSError", err)
+	}
+	if jsErr.Filename != "src/app.js" || jsErr.LineNumber != 4 {
+		t.Errorf("run() err location = %s:%d, want %s:%d", jsErr.Filename, jsErr.LineNumber, "src/app.js", 4)
+	}
+}
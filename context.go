@@ -6,6 +6,10 @@ package gomonkey
 import "C"
 import (
 	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"runtime/debug"
 	"sync"
 	"time"
 	"unsafe"
@@ -15,8 +19,21 @@ import (
 type Context struct {
 	options     contextOptions
 	ref         uint
-	functions   map[string]FunctionCallback
+	functions   map[uint]CallInfoCallback
+	functionSeq uint
 	muFunctions sync.RWMutex
+	hostData    map[uint]any
+	hostDataSeq uint
+	muHostData  sync.RWMutex
+	errorValues map[*Value]struct{}
+	muErrors    sync.Mutex
+	modules     map[string]*Module
+	imports     map[moduleImport]*Module
+	owned       map[C.ModulePtr]*Module
+	pending     map[*DynamicImport]struct{}
+	muModules   sync.Mutex
+	sourceMaps  map[string]*SourceMap
+	muSources   sync.RWMutex
 	ptr         C.ContextPtr
 }
 
@@ -27,8 +44,27 @@ type contextOptions struct {
 	gcMaxBytes           uint
 	gcIncrementalEnabled uint
 	gcSliceTimeBudgetMs  uint
+	moduleLoader         ModuleLoader
+	dynamicImport        DynamicImportCallback
+	moduleMetadata       ModuleMetadataCallback
+	globalResolve        GlobalResolveCallback
+	panicHandler         PanicHandler
+	errorValues          bool
+	sourceMaps           bool
+	sourceMapFS          fs.FS
 }
 
+// GlobalResolveCallback represents a callback defining a lazy property of the global object.
+//
+// The callback is called the first time a script looks up an undefined name on the global object. It may define the
+// property on the global object, in which case the property is then resolved without calling the callback again.
+type GlobalResolveCallback func(ctx *Context, global *Object, name string) error
+
+// PanicHandler represents a handler notified of the panics recovered in the Go callbacks and hooks.
+//
+// The panics of the handler itself are recovered and ignored.
+type PanicHandler func(ctx *Context, err *PanicError)
+
 // ContextOptionFunc represents a context option function.
 type ContextOptionFunc func(c *Context) error
 
@@ -46,11 +82,17 @@ func NewContext(options ...ContextOptionFunc) (*Context, error) {
 		}
 	}
 
-	context.functions = map[string]FunctionCallback{}
+	context.functions = map[uint]CallInfoCallback{}
+	context.hostData = map[uint]any{}
+	context.errorValues = map[*Value]struct{}{}
+	context.modules = map[string]*Module{}
+	context.imports = map[moduleImport]*Module{}
+	context.owned = map[C.ModulePtr]*Module{}
+	context.pending = map[*DynamicImport]struct{}{}
+	context.sourceMaps = map[string]*SourceMap{}
 
 	muContexts.Lock()
-	contextsSeq += 1
-	context.ref = contextsSeq
+	context.ref = nextHandle(&contextsSeq, contexts)
 	contexts[context.ref] = context
 	muContexts.Unlock()
 
@@ -60,6 +102,7 @@ func NewContext(options ...ContextOptionFunc) (*Context, error) {
 		gcMaxBytes:           C.uint(context.options.gcMaxBytes),
 		gcIncrementalEnabled: C.uint(context.options.gcIncrementalEnabled),
 		gcSliceTimeBudgetMs:  C.uint(context.options.gcSliceTimeBudgetMs),
+		globalResolve:        C.bool(context.options.globalResolve != nil),
 	})
 	if ptr == nil {
 		return nil, errors.New("new context")
@@ -111,8 +154,91 @@ func WithGCSliceTimeBudget(d time.Duration) ContextOptionFunc {
 	}
 }
 
+// WithModuleLoader sets the loader of the imported modules.
+func WithModuleLoader(loader ModuleLoader) ContextOptionFunc {
+	return func(c *Context) error {
+		c.options.moduleLoader = loader
+		return nil
+	}
+}
+
+// WithDynamicImport sets the callback handling the dynamic imports.
+func WithDynamicImport(callback DynamicImportCallback) ContextOptionFunc {
+	return func(c *Context) error {
+		c.options.dynamicImport = callback
+		return nil
+	}
+}
+
+// WithModuleMetadata sets the callback populating the import.meta object of the modules.
+func WithModuleMetadata(callback ModuleMetadataCallback) ContextOptionFunc {
+	return func(c *Context) error {
+		c.options.moduleMetadata = callback
+		return nil
+	}
+}
+
+// WithPanicHandler sets the handler notified of the panics recovered in the Go callbacks and hooks.
+func WithPanicHandler(handler PanicHandler) ContextOptionFunc {
+	return func(c *Context) error {
+		c.options.panicHandler = handler
+		return nil
+	}
+}
+
+// WithGlobalResolve sets the callback defining the lazy properties of the global object.
+func WithGlobalResolve(callback GlobalResolveCallback) ContextOptionFunc {
+	return func(c *Context) error {
+		c.options.globalResolve = callback
+		return nil
+	}
+}
+
+// WithErrorValues enables the capture of the thrown JS values by the errors.
+//
+// The captured values are kept alive until the errors are released or the context is destroyed.
+func WithErrorValues() ContextOptionFunc {
+	return func(c *Context) error {
+		c.options.errorValues = true
+		return nil
+	}
+}
+
+// WithSourceMaps enables the loading of the source maps referenced by the scripts and modules compiled by the context.
+//
+// The external source maps are read from the given file system, or ignored if it is nil. The source maps which cannot
+// be loaded are ignored, LoadSourceMap returns their errors.
+func WithSourceMaps(fsys fs.FS) ContextOptionFunc {
+	return func(c *Context) error {
+		c.options.sourceMaps = true
+		c.options.sourceMapFS = fsys
+		return nil
+	}
+}
+
 // Destroy destroys the context.
 func (c *Context) Destroy() {
+	c.muModules.Lock()
+	for imp := range c.pending {
+		C.ReleaseDynamicImport(imp.ptr)
+		imp.ptr = nil
+		delete(c.pending, imp)
+	}
+	for ptr, module := range c.owned {
+		module.Release()
+		delete(c.owned, ptr)
+	}
+	c.modules = map[string]*Module{}
+	c.imports = map[moduleImport]*Module{}
+	c.muModules.Unlock()
+
+	c.muErrors.Lock()
+	for value := range c.errorValues {
+		value.Release()
+		delete(c.errorValues, value)
+	}
+	c.muErrors.Unlock()
+
 	C.DestroyContext(c.ptr)
 
 	muContexts.Lock()
@@ -120,11 +246,86 @@ func (c *Context) Destroy() {
 	muContexts.Unlock()
 }
 
+// registerErrorValue registers the thrown value of an error, released with the context.
+func (c *Context) registerErrorValue(value *Value) {
+	c.muErrors.Lock()
+	c.errorValues[value] = struct{}{}
+	c.muErrors.Unlock()
+}
+
+// unregisterErrorValue unregisters the thrown value of an error if it has not been released.
+func (c *Context) unregisterErrorValue(value *Value) bool {
+	c.muErrors.Lock()
+	_, ok := c.errorValues[value]
+	delete(c.errorValues, value)
+	c.muErrors.Unlock()
+	return ok
+}
+
+// RegisterSourceMap registers the source map of the named script or module.
+func (c *Context) RegisterSourceMap(name string, m *SourceMap) {
+	c.muSources.Lock()
+	c.sourceMaps[name] = m
+	c.muSources.Unlock()
+}
+
+// UnregisterSourceMap unregisters the source map of the named script or module.
+func (c *Context) UnregisterSourceMap(name string) {
+	c.muSources.Lock()
+	delete(c.sourceMaps, name)
+	c.muSources.Unlock()
+}
+
+// sourceMap returns the source map of the named script or module, falling back to the global source maps.
+func (c *Context) sourceMap(name string) *SourceMap {
+	c.muSources.RLock()
+	m, ok := c.sourceMaps[name]
+	c.muSources.RUnlock()
+	if ok {
+		return m
+	}
+	muSourceMaps.RLock()
+	m = sourceMaps[name]
+	muSourceMaps.RUnlock()
+	return m
+}
+
+// loadSourceMap registers the source map referenced by the named code, if the source maps are enabled.
+func (c *Context) loadSourceMap(name string, code []byte) {
+	if !c.options.sourceMaps {
+		return
+	}
+	if m := loadSourceMap(c.options.sourceMapFS, name, code); m != nil {
+		c.RegisterSourceMap(name, m)
+	}
+}
+
+// registerStencilSourceMap registers the source map carried by a stencil, if any.
+func (c *Context) registerStencilSourceMap(stencil *Stencil) {
+	if stencil.sourceMap != nil {
+		c.RegisterSourceMap(stencil.name, stencil.sourceMap)
+	}
+}
+
 // RequestInterrupt requests the context interruption.
 func (c *Context) RequestInterrupt() {
 	C.RequestInterruptContext(c.ptr)
 }
 
+// GC runs a full garbage collection of the context.
+func (c *Context) GC() {
+	C.GCContext(c.ptr)
+}
+
+// RunJobs runs the pending jobs until the job queue is empty.
+func (c *Context) RunJobs() error {
+	result := C.RunJobs(c.ptr)
+	if !result.ok {
+		return newJSError(result.err)
+	}
+	return nil
+}
+
 // Global returns the global object.
 func (c *Context) Global() (*Object, error) {
 	result := C.GetGlobalObject(c.ptr)
@@ -145,15 +346,85 @@ func goFunctionContext(contextRef C.uint) C.ContextPtr {
 	return ctx.ptr
 }
 
-// registerCallback registers a function callback.
-func (c *Context) registerCallback(name string, cb FunctionCallback) {
+// nextHandle returns the next free handle of a registry, wrapping around the unsigned 32-bit values stored in JS.
+func nextHandle[T any](seq *uint, registry map[uint]T) uint {
+	for {
+		*seq = uint(uint32(*seq + 1))
+		if _, ok := registry[*seq]; *seq != 0 && !ok {
+			return *seq
+		}
+	}
+}
+
+// registerCallback registers a function callback and returns its handle.
+func (c *Context) registerCallback(cb CallInfoCallback) uint {
 	c.muFunctions.Lock()
-	c.functions[name] = cb
+	handle := nextHandle(&c.functionSeq, c.functions)
+	c.functions[handle] = cb
 	c.muFunctions.Unlock()
+	return handle
+}
+
+// unregisterCallback unregisters a function callback.
+func (c *Context) unregisterCallback(handle uint) {
+	c.muFunctions.Lock()
+	delete(c.functions, handle)
+	c.muFunctions.Unlock()
+}
+
+//export goFunctionFinalize
+func goFunctionFinalize(contextRef C.uint, handle C.uint) {
+	muContexts.RLock()
+	ctx, ok := contexts[uint(contextRef)]
+	muContexts.RUnlock()
+	if !ok {
+		return
+	}
+	ctx.unregisterCallback(uint(handle))
+}
+
+// registerHostData registers the Go value of a host object and returns its handle.
+func (c *Context) registerHostData(data any) uint {
+	c.muHostData.Lock()
+	handle := nextHandle(&c.hostDataSeq, c.hostData)
+	c.hostData[handle] = data
+	c.muHostData.Unlock()
+	return handle
+}
+
+// unregisterHostData unregisters the Go value of a host object.
+func (c *Context) unregisterHostData(handle uint) {
+	c.muHostData.Lock()
+	delete(c.hostData, handle)
+	c.muHostData.Unlock()
+}
+
+//export goHostObjectFinalize
+func goHostObjectFinalize(contextRef C.uint, handle C.uint) {
+	muContexts.RLock()
+	ctx, ok := contexts[uint(contextRef)]
+	muContexts.RUnlock()
+	if !ok {
+		return
+	}
+	ctx.muHostData.Lock()
+	data, ok := ctx.hostData[uint(handle)]
+	delete(ctx.hostData, uint(handle))
+	ctx.muHostData.Unlock()
+	if !ok {
+		return
+	}
+	if finalizer, ok := data.(HostDataFinalizer); ok {
+		ctx.guard(func() error {
+			finalizer.Finalize()
+			return nil
+		})
+	}
 }
 
 //export goFunctionCallback
-func goFunctionCallback(contextRef C.uint, name *C.char, argc C.uint, vp *C.ValuePtr) C.ResultGoFunctionCallback {
+func goFunctionCallback(contextRef C.uint, handle C.uint, thisv C.ValuePtr, newTarget C.ValuePtr, callee C.ValuePtr,
+	argc C.uint, vp *C.ValuePtr) C.ResultGoFunctionCallback {
 	result := C.ResultGoFunctionCallback{}
 
 	muContexts.RLock()
@@ -165,11 +436,10 @@ func goFunctionCallback(contextRef C.uint, name *C.char, argc C.uint, vp *C.Valu
 	}
 
 	ctx.muFunctions.RLock()
-	gName := C.GoString(name)
-	callback, ok := ctx.functions[gName]
+	callback, ok := ctx.functions[uint(handle)]
 	ctx.muFunctions.RUnlock()
 	if !ok {
-		result.err = C.CString("invalid function name")
+		result.err = C.CString("invalid function handle")
 		return result
 	}
 
@@ -180,9 +450,15 @@ func goFunctionCallback(contextRef C.uint, name *C.char, argc C.uint, vp *C.Valu
 		values = append(values, value)
 	}
 
-	val, err := callback(values)
+	val, err := ctx.invokeCallback(callback, &CallInfo{
+		Context:   ctx,
+		This:      &Value{ptr: thisv, ctx: ctx},
+		NewTarget: &Value{ptr: newTarget, ctx: ctx},
+		Callee:    &Function{&Value{ptr: callee, ctx: ctx}},
+		Args:      values,
+	})
 	if err != nil {
-		result.err = C.CString(err.Error())
+		setCallbackError(ctx, &result, err)
 		return result
 	}
 	if val != nil {
@@ -192,15 +468,258 @@ func goFunctionCallback(contextRef C.uint, name *C.char, argc C.uint, vp *C.Valu
 	return result
 }
 
+// invokeCallback calls a Go callback, recovering from its panics.
+func (c *Context) invokeCallback(callback CallInfoCallback, info *CallInfo) (val *Value, err error) {
+	defer c.recoverPanic(&err)
+	return callback(info)
+}
+
+// guard calls a Go hook, recovering from its panics.
+func (c *Context) guard(fn func() error) (err error) {
+	defer c.recoverPanic(&err)
+	return fn()
+}
+
+// recoverPanic recovers from a panic of a Go callback or hook and notifies the panic handler.
+func (c *Context) recoverPanic(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	panicErr := &PanicError{Value: r, Stack: debug.Stack()}
+	if c.options.panicHandler != nil {
+		func() {
+			defer func() {
+				recover()
+			}()
+			c.options.panicHandler(c, panicErr)
+		}()
+	}
+	*err = panicErr
+}
+
+// setCallbackError sets the JS exception thrown by a Go callback returning an error.
+func setCallbackError(ctx *Context, result *C.ResultGoFunctionCallback, err error) {
+	var thrown *thrownValue
+	if errors.As(err, &thrown) {
+		value, err := thrown.v.clone()
+		if err != nil {
+			result.err = C.CString(fmt.Sprintf("cannot throw value: %s", err))
+			return
+		}
+		result.ptr = value.ptr
+		result.thrown = true
+		return
+	}
+	result.errHandle = C.uint(ctx.registerHostData(err))
+	var callbackErr *CallbackError
+	if errors.As(err, &callbackErr) {
+		if len(callbackErr.Properties) > 0 {
+			props, err := toValue(ctx, reflect.ValueOf(callbackErr.Properties))
+			if err != nil {
+				result.err = C.CString(fmt.Sprintf("cannot convert error properties: %s", err))
+				result.errName = C.CString("TypeError")
+				return
+			}
+			result.errProps = props.ptr
+		}
+		result.err = C.CString(callbackErr.Message)
+		if callbackErr.Name != "" {
+			result.errName = C.CString(callbackErr.Name)
+		}
+		return
+	}
+	var panicErr *PanicError
+	var typeErr *typeError
+	switch {
+	case errors.As(err, &panicErr):
+		result.errName = C.CString("InternalError")
+		props, err := toValue(ctx, reflect.ValueOf(map[string]any{"goStack": string(panicErr.Stack)}))
+		if err == nil {
+			result.errProps = props.ptr
+		}
+	case errors.As(err, &typeErr):
+		result.errName = C.CString("TypeError")
+	}
+	result.err = C.CString(err.Error())
+}
+
+// loadModule returns an imported module from the cache or from the module loader.
+func (c *Context) loadModule(referrer string, specifier string) (*Module, error) {
+	c.muModules.Lock()
+	imported, ok := c.imports[moduleImport{referrer, specifier}]
+	c.muModules.Unlock()
+	if ok {
+		return imported, nil
+	}
+
+	if c.options.moduleLoader == nil {
+		return nil, fmt.Errorf("cannot import module %q: no module loader", specifier)
+	}
+	name, err := c.options.moduleLoader.Resolve(referrer, specifier)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve module %q: %w", specifier, err)
+	}
+
+	c.muModules.Lock()
+	defer c.muModules.Unlock()
+	if module, ok := c.modules[name]; ok {
+		return module, nil
+	}
+	code, err := c.options.moduleLoader.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load module %q: %w", name, err)
+	}
+	module, err := c.CompileModule(name, code)
+	if err != nil {
+		return nil, err
+	}
+	c.modules[name] = module
+	c.owned[module.ptr] = module
+	return module, nil
+}
+
+//export goModuleResolve
+func goModuleResolve(contextRef C.uint, referrer *C.char, specifier *C.char) C.ResultGoModuleResolve {
+	result := C.ResultGoModuleResolve{}
+
+	muContexts.RLock()
+	ctx, ok := contexts[uint(contextRef)]
+	muContexts.RUnlock()
+	if !ok {
+		result.err = C.CString("invalid context ref")
+		return result
+	}
+
+	var module *Module
+	if err := ctx.guard(func() (err error) {
+		module, err = ctx.loadModule(C.GoString(referrer), C.GoString(specifier))
+		return err
+	}); err != nil {
+		result.err = C.CString(err.Error())
+		result.errHandle = C.uint(ctx.registerHostData(err))
+		var jsErr *JSError
+		if errors.As(err, &jsErr) && jsErr.Name != "" {
+			result.errName = C.CString(jsErr.Name)
+		}
+		return result
+	}
+	result.ptr = module.ptr
+	return result
+}
+
+// registerImport registers the module of a finished dynamic import, owned by the context.
+func (c *Context) registerImport(referrer string, specifier string, module *Module) {
+	c.muModules.Lock()
+	c.imports[moduleImport{referrer, specifier}] = module
+	if _, ok := c.owned[module.ptr]; !ok {
+		c.owned[module.ptr] = module
+	}
+	c.muModules.Unlock()
+}
+
+// registerDynamicImport registers a pending dynamic import, released with the context if it is not settled.
+func (c *Context) registerDynamicImport(imp *DynamicImport) {
+	c.muModules.Lock()
+	c.pending[imp] = struct{}{}
+	c.muModules.Unlock()
+}
+
+// unregisterDynamicImport unregisters a settled dynamic import.
+func (c *Context) unregisterDynamicImport(imp *DynamicImport) {
+	c.muModules.Lock()
+	delete(c.pending, imp)
+	c.muModules.Unlock()
+}
+
+//export goModuleDynamicImport
+func goModuleDynamicImport(contextRef C.uint, ptr C.DynamicImportPtr, referrer *C.char, specifier *C.char) *C.char {
+	muContexts.RLock()
+	ctx, ok := contexts[uint(contextRef)]
+	muContexts.RUnlock()
+	if !ok {
+		C.ReleaseDynamicImport(ptr)
+		return C.CString("invalid context ref")
+	}
+
+	imp := &DynamicImport{
+		Referrer:  C.GoString(referrer),
+		Specifier: C.GoString(specifier),
+		ptr:       ptr,
+		ctx:       ctx,
+	}
+	ctx.registerDynamicImport(imp)
+	err := ctx.guard(func() error {
+		if ctx.options.dynamicImport != nil {
+			ctx.options.dynamicImport(ctx, imp)
+			return nil
+		}
+		module, err := ctx.loadModule(imp.Referrer, imp.Specifier)
+		if err != nil {
+			return imp.Fail(err)
+		}
+		return imp.finish(module)
+	})
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) && imp.ptr != nil {
+		err = imp.Fail(panicErr)
+	}
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+//export goModuleMetadata
+func goModuleMetadata(contextRef C.uint, name *C.char, meta C.ValuePtr) *C.char {
+	muContexts.RLock()
+	ctx, ok := contexts[uint(contextRef)]
+	muContexts.RUnlock()
+	if !ok {
+		return C.CString("invalid context ref")
+	}
+	if ctx.options.moduleMetadata == nil {
+		return nil
+	}
+
+	if err := ctx.guard(func() error {
+		return ctx.options.moduleMetadata(ctx, C.GoString(name), &Object{&Value{meta, ctx}})
+	}); err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+//export goGlobalResolve
+func goGlobalResolve(contextRef C.uint, name *C.char, global C.ValuePtr) *C.char {
+	muContexts.RLock()
+	ctx, ok := contexts[uint(contextRef)]
+	muContexts.RUnlock()
+	if !ok {
+		return C.CString("invalid context ref")
+	}
+	if ctx.options.globalResolve == nil {
+		return nil
+	}
+
+	if err := ctx.guard(func() error {
+		return ctx.options.globalResolve(ctx, &Object{&Value{global, ctx}}, C.GoString(name))
+	}); err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
 // newFunction creates a new JS function.
-func (c *Context) newFunction(name string, callback FunctionCallback) (*Value, error) {
+func (c *Context) newFunction(name string, callback CallInfoCallback) (*Value, error) {
+	handle := c.registerCallback(callback)
 	cName := C.CString(name)
-	result := C.NewFunction(c.ptr, cName)
+	result := C.NewFunction(c.ptr, cName, C.uint(handle))
 	C.free(unsafe.Pointer(cName))
 	if !result.ok {
+		c.unregisterCallback(handle)
 		return nil, newJSError(result.err)
 	}
-	c.registerCallback(name, callback)
 	return valueFromResultWithJSError(c, result)
 }
 
@@ -238,13 +757,83 @@ func (c *Context) DefineElement(object *Object, index uint, value *Value, attrs
 // DefineFunction defines a new JS function and sets it as a property of the given JS object.
 func (c *Context) DefineFunction(object *Object, name string, callback FunctionCallback, args uint,
 	attrs PropertyAttributes) error {
+	return c.DefineFunctionWithCallInfo(object, name, callback.withCallInfo(), args, attrs)
+}
+
+// DefineFunctionWithCallInfo defines a new JS function receiving the call information and sets it as a property of
+// the given JS object.
+func (c *Context) DefineFunctionWithCallInfo(object *Object, name string, callback CallInfoCallback, args uint,
+	attrs PropertyAttributes) error {
+	handle := c.registerCallback(callback)
+	cName := C.CString(name)
+	result := C.DefineFunction(c.ptr, object.AsValue().ptr, cName, C.uint(handle), C.uint(args), C.uint(attrs))
+	C.free(unsafe.Pointer(cName))
+	if !result.ok {
+		c.unregisterCallback(handle)
+		return newJSError(result.err)
+	}
+	return nil
+}
+
+// DefineGoFunc defines a new JS function calling a Go function and sets it as a property of the given JS object.
+//
+// The JS arguments are converted to the parameters of the Go function and its result is converted to a JS value. The
+// conversions follow the rules of Bind. The Go function returns at most one value followed by an optional error, which
+// is thrown as a JS error, and a conversion failure is thrown as a JS TypeError.
+func (c *Context) DefineGoFunc(object *Object, name string, fn any) error {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return fmt.Errorf("cannot define %T: not a function", fn)
+	}
+	callback, err := reflectCallback(c, rv)
+	if err != nil {
+		return err
+	}
+	return c.DefineFunctionWithCallInfo(object, name, callback, reflectArgsCount(rv.Type()), PropertyAttributeDefault)
+}
+
+// DefineAccessor defines a new accessor property on the given JS object.
+//
+// The getter or the setter can be nil. The setter receives the assigned value as its only argument.
+func (c *Context) DefineAccessor(object *Object, name string, getter FunctionCallback, setter FunctionCallback,
+	attrs PropertyAttributes) error {
+	var getterCallback, setterCallback CallInfoCallback
+	if getter != nil {
+		getterCallback = getter.withCallInfo()
+	}
+	if setter != nil {
+		setterCallback = setter.withCallInfo()
+	}
+	return c.DefineAccessorWithCallInfo(object, name, getterCallback, setterCallback, attrs)
+}
+
+// DefineAccessorWithCallInfo defines a new accessor property on the given JS object with a getter and a setter
+// receiving the call information.
+func (c *Context) DefineAccessorWithCallInfo(object *Object, name string, getter CallInfoCallback,
+	setter CallInfoCallback, attrs PropertyAttributes) error {
+	if getter == nil && setter == nil {
+		return errors.New("define accessor: no getter or setter")
+	}
+	var getterHandle, setterHandle uint
+	if getter != nil {
+		getterHandle = c.registerCallback(getter)
+	}
+	if setter != nil {
+		setterHandle = c.registerCallback(setter)
+	}
 	cName := C.CString(name)
-	result := C.DefineFunction(c.ptr, object.AsValue().ptr, cName, C.uint(args), C.uint(attrs))
+	result := C.DefineAccessor(c.ptr, object.AsValue().ptr, cName, C.uint(getterHandle), C.uint(setterHandle),
+		C.uint(attrs))
 	C.free(unsafe.Pointer(cName))
 	if !result.ok {
+		if getterHandle != 0 {
+			c.unregisterCallback(getterHandle)
+		}
+		if setterHandle != 0 {
+			c.unregisterCallback(setterHandle)
+		}
 		return newJSError(result.err)
 	}
-	c.registerCallback(name, callback)
 	return nil
 }
 
@@ -291,6 +880,7 @@ func (c *Context) Evaluate(code []byte) (*Value, error) {
 
 // CompileScript compiles a JS code into a script.
 func (c *Context) CompileScript(name string, code []byte) (*Script, error) {
+	c.loadSourceMap(name, code)
 	cName := C.CString(name)
 	cCode := C.CString(string(code))
 	result := C.CompileScript(c.ptr, cName, cCode)
@@ -309,12 +899,44 @@ func (c *Context) ExecuteScript(script *Script) (*Value, error) {
 }
 
 // Execute executes a script from a stencil.
+//
+// The source map carried by the stencil is registered for its script.
 func (c *Context) ExecuteScriptFromStencil(stencil *Stencil) (*Value, error) {
+	c.registerStencilSourceMap(stencil)
 	result := C.ExecuteScriptFromStencil(c.ptr, stencil.ptr)
 	return valueFromResultWithJSError(c, result)
 }
 
+// CompileModule compiles a JS code into a module.
+func (c *Context) CompileModule(name string, code []byte) (*Module, error) {
+	c.loadSourceMap(name, code)
+	cName := C.CString(name)
+	cCode := C.CString(string(code))
+	result := C.CompileModule(c.ptr, cName, cCode)
+	C.free(unsafe.Pointer(cName))
+	C.free(unsafe.Pointer(cCode))
+	if !result.ok {
+		return nil, newJSError(result.err)
+	}
+	return &Module{result.ptr, c}, nil
+}
+
+// InstantiateModuleFromStencil instantiates a module from a stencil.
+//
+// The source map carried by the stencil is registered for its module.
+func (c *Context) InstantiateModuleFromStencil(stencil *Stencil) (*Module, error) {
+	c.registerStencilSourceMap(stencil)
+	result := C.InstantiateModuleFromStencil(c.ptr, stencil.ptr)
+	if !result.ok {
+		return nil, newJSError(result.err)
+	}
+	return &Module{result.ptr, c}, nil
+}
+
 // FrontendContext represents a JS frontend context.
+//
+// The compilations of all the frontend contexts run one at a time in a JS context shared with the stencil encoding,
+// on a dedicated thread started on first use and stopped by ShutDown.
 type FrontendContext struct {
 	options frontendContextOptions
 	ptr     C.FrontendContextPtr
@@ -323,6 +945,8 @@ type FrontendContext struct {
 // frontendContextOptions implements the frontend context options.
 type frontendContextOptions struct {
 	nativeStackSize uint
+	sourceMaps      bool
+	sourceMapFS     fs.FS
 }
 
 // FrontendContextOptionFunc represents a frontend context option function.
@@ -349,7 +973,8 @@ func NewFrontendContext(options ...FrontendContextOptionFunc) (*FrontendContext,
 	return context, nil
 }
 
-// WithNativeStackSize sets the native stack size in bytes.
+// WithFrontendNativeStackSize sets the native stack size in bytes of the compilations, capped by the stack quota of
+// their dedicated thread.
 func WithFrontendNativeStackSize(size uint) FrontendContextOptionFunc {
 	return func(c *FrontendContext) error {
 		c.options.nativeStackSize = size
@@ -357,6 +982,19 @@ func WithFrontendNativeStackSize(size uint) FrontendContextOptionFunc {
 	}
 }
 
+// WithFrontendSourceMaps enables the loading of the source maps referenced by the scripts and modules compiled to
+// stencils, which carry them to the contexts executing or instantiating them.
+//
+// The external source maps are read from the given file system, or ignored if it is nil. The source maps which cannot
+// be loaded are ignored, LoadSourceMap returns their errors.
+func WithFrontendSourceMaps(fsys fs.FS) FrontendContextOptionFunc {
+	return func(c *FrontendContext) error {
+		c.options.sourceMaps = true
+		c.options.sourceMapFS = fsys
+		return nil
+	}
+}
+
 // Destroy destroys the context.
 func (c *FrontendContext) Destroy() {
 	C.DestroyFrontendContext(c.ptr)
@@ -364,15 +1002,52 @@ func (c *FrontendContext) Destroy() {
 
 // CompileScriptToStencil compiles a script to a stencil.
 func (c *FrontendContext) CompileScriptToStencil(name string, code []byte) (*Stencil, error) {
+	m := c.loadSourceMap(name, code)
 	cName := C.CString(name)
 	cCode := C.CString(string(code))
+	defer C.free(unsafe.Pointer(cName))
+	defer C.free(unsafe.Pointer(cCode))
 	result := C.CompileScriptToStencil(c.ptr, cName, cCode)
-	C.free(unsafe.Pointer(cName))
-	C.free(unsafe.Pointer(cCode))
 	if !result.ok {
-		return nil, errors.New("compile script")
+		return nil, newFrontendError(result.err, name, m)
+	}
+	return &Stencil{ptr: result.ptr, name: name, sourceMap: m}, nil
+}
+
+// CompileModuleToStencil compiles a module to a stencil.
+func (c *FrontendContext) CompileModuleToStencil(name string, code []byte) (*Stencil, error) {
+	m := c.loadSourceMap(name, code)
+	cName := C.CString(name)
+	cCode := C.CString(string(code))
+	defer C.free(unsafe.Pointer(cName))
+	defer C.free(unsafe.Pointer(cCode))
+	result := C.CompileModuleToStencil(c.ptr, cName, cCode)
+	if !result.ok {
+		return nil, newFrontendError(result.err, name, m)
+	}
+	return &Stencil{ptr: result.ptr, name: name, sourceMap: m}, nil
+}
+
+// newFrontendError creates a new error of a failed compilation, at its original position if the code has a source map.
+func newFrontendError(e C.Error, name string, m *SourceMap) *JSError {
+	err := newJSError(e)
+	if m != nil {
+		err.rewrite(func(filename string) *SourceMap {
+			if filename == name {
+				return m
+			}
+			return nil
+		})
+	}
+	return err
+}
+
+// loadSourceMap returns the source map referenced by the named code, if the source maps are enabled.
+func (c *FrontendContext) loadSourceMap(name string, code []byte) *SourceMap {
+	if !c.options.sourceMaps {
+		return nil
 	}
-	return &Stencil{ptr: result.ptr}, nil
+	return loadSourceMap(c.options.sourceMapFS, name, code)
 }
 
 // PropertyAttributes represents the attributes of a property.
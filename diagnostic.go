@@ -0,0 +1,67 @@
+package gomonkey
+
+// #include "gomonkey.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// Diagnostic represents a diagnostic reported by the parser.
+//
+// The lines and columns are 1-based. The end position is exclusive and spans the token at the start position.
+type Diagnostic struct {
+	Kind      string
+	Message   string
+	Filename  string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// CheckSyntax parses a script and returns its diagnostics.
+//
+// The failures of the parser which are not diagnostics, such as running out of memory, are returned as errors.
+func (c *FrontendContext) CheckSyntax(name string, code []byte) ([]Diagnostic, error) {
+	return c.checkSyntax(name, code, false)
+}
+
+// CheckModuleSyntax parses a module and returns its diagnostics.
+//
+// The failures of the parser which are not diagnostics, such as running out of memory, are returned as errors.
+func (c *FrontendContext) CheckModuleSyntax(name string, code []byte) ([]Diagnostic, error) {
+	return c.checkSyntax(name, code, true)
+}
+
+// checkSyntax parses the code and returns its diagnostics.
+func (c *FrontendContext) checkSyntax(name string, code []byte, module bool) ([]Diagnostic, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cCode := C.CString(string(code))
+	defer C.free(unsafe.Pointer(cCode))
+	result := C.CheckSyntax(c.ptr, cName, cCode, C.bool(module))
+	if result.ok {
+		return nil, nil
+	}
+	if result.err.message == nil {
+		return nil, errors.New("check syntax")
+	}
+	err := newJSError(result.err)
+	if errors.Is(err, ErrOutOfMemory) || errors.Is(err, ErrStackOverflow) || errors.Is(err, ErrInterrupted) ||
+		err.LineNumber == 0 {
+		return nil, err
+	}
+
+	d := Diagnostic{
+		Kind:      err.Name,
+		Message:   err.Message,
+		Filename:  err.Filename,
+		Line:      err.LineNumber,
+		Column:    err.ColumnNumber,
+		EndLine:   err.LineNumber,
+		EndColumn: err.ColumnNumber + int(result.tokenLength),
+	}
+	return []Diagnostic{d}, nil
+}
@@ -4,36 +4,223 @@ package gomonkey
 // #include <stdlib.h>
 import "C"
 import (
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"unsafe"
 )
 
+var (
+	// ErrInterrupted is matched by the errors of the executions interrupted by RequestInterrupt.
+	ErrInterrupted = errors.New("execution interrupted")
+	// ErrOutOfMemory is matched by the errors of the executions running out of memory.
+	ErrOutOfMemory = errors.New("out of memory")
+	// ErrStackOverflow is matched by the errors of the executions exceeding the recursion limit.
+	ErrStackOverflow = errors.New("stack overflow")
+	// ErrSyntax is matched by the errors of the compilations failing on invalid syntax.
+	ErrSyntax = errors.New("syntax error")
+)
+
 // JSError implements a JS error.
 type JSError struct {
-	Message     string
-	Filename    string
-	LineNumber  int
-	ErrorNumber int
+	Message      string
+	Filename     string
+	LineNumber   int
+	ColumnNumber int
+	ErrorNumber  int
+	// Name is the error name, such as TypeError.
+	Name string
+	// Stack is the stack trace of the error.
+	Stack []StackFrame
+	// Cause is the error cause, if any.
+	Cause *JSError
+	// Value is the thrown JS value, captured with the WithErrorValues option. It is released with the context, or
+	// earlier with Release.
+	Value *Value
+	err   error
+	kind  C.ErrorKind
+}
+
+// StackFrame represents a frame of a JS stack trace.
+type StackFrame struct {
+	Function     string
+	Filename     string
+	LineNumber   int
+	ColumnNumber int
 }
 
+// maxErrorCauses is the maximum depth of the error causes.
+const maxErrorCauses = 16
+
 // newJSError creates a new error.
 func newJSError(e C.Error) *JSError {
 	err := &JSError{
-		Message:     C.GoString(e.message),
-		Filename:    C.GoString(e.filename),
-		LineNumber:  int(e.lineno),
-		ErrorNumber: int(e.number),
+		Message:      C.GoString(e.message),
+		Filename:     C.GoString(e.filename),
+		LineNumber:   int(e.lineno),
+		ColumnNumber: int(e.column),
+		ErrorNumber:  int(e.number),
+		Name:         C.GoString(e.name),
+		kind:         e.kind,
+	}
+	if e.handle != 0 {
+		err.err = lookupCallbackError(uint(e.contextRef), uint(e.handle))
 	}
 	C.free(unsafe.Pointer(e.message))
+
+	if e.exception {
+		muContexts.RLock()
+		ctx, ok := contexts[uint(e.contextRef)]
+		muContexts.RUnlock()
+		if ok && ctx.ptr != nil {
+			err.setException(ctx, C.TakeException(ctx.ptr), 0)
+			err.applySourceMaps(ctx)
+		}
+	}
+	return err
+}
+
+// setException sets the thrown value, the stack trace and the causes of the error.
+func (e *JSError) setException(ctx *Context, result C.ResultException, depth int) {
+	if !result.ok {
+		return
+	}
+	value := &Value{result.ptr, ctx}
+	if ctx.options.errorValues {
+		e.Value = value
+		ctx.registerErrorValue(value)
+	} else {
+		defer value.Release()
+	}
+	if result.stack != nil {
+		e.Stack = parseStack(C.GoString(result.stack))
+		C.free(unsafe.Pointer(result.stack))
+	}
+
+	if depth >= maxErrorCauses {
+		return
+	}
+	cause := C.GetErrorCause(value.ptr)
+	if cause.ok {
+		e.Cause = newJSError(cause.err)
+		e.Cause.setException(ctx, cause, depth+1)
+	}
+}
+
+// applySourceMaps rewrites the locations of the error, its stack frames and its causes to their original positions.
+func (e *JSError) applySourceMaps(ctx *Context) {
+	e.rewrite(ctx.sourceMap)
+}
+
+// rewrite rewrites the locations of the error, its stack frames and its causes with the source maps of their files.
+func (e *JSError) rewrite(sourceMap func(name string) *SourceMap) {
+	for err := e; err != nil; err = err.Cause {
+		if m := sourceMap(err.Filename); m != nil {
+			if pos, ok := m.Lookup(err.LineNumber, err.ColumnNumber); ok {
+				err.Filename, err.LineNumber, err.ColumnNumber = pos.Source, pos.Line, pos.Column
+			}
+		}
+		for i, frame := range err.Stack {
+			m := sourceMap(frame.Filename)
+			if m == nil {
+				continue
+			}
+			if pos, ok := m.Lookup(frame.LineNumber, frame.ColumnNumber); ok {
+				err.Stack[i].Filename, err.Stack[i].LineNumber, err.Stack[i].ColumnNumber = pos.Source, pos.Line,
+					pos.Column
+			}
+		}
+	}
+}
+
+// parseStack parses a JS stack trace.
+func parseStack(stack string) []StackFrame {
+	var frames []StackFrame
+	for _, line := range strings.Split(stack, "\n") {
+		function, location, ok := strings.Cut(line, "@")
+		if !ok {
+			continue
+		}
+		frame := StackFrame{
+			Function: function,
+		}
+		if i := strings.LastIndexByte(location, ':'); i >= 0 {
+			if column, err := strconv.Atoi(location[i+1:]); err == nil {
+				frame.ColumnNumber = column
+				location = location[:i]
+			}
+		}
+		if i := strings.LastIndexByte(location, ':'); i >= 0 {
+			if line, err := strconv.Atoi(location[i+1:]); err == nil {
+				frame.LineNumber = line
+				location = location[:i]
+			}
+		}
+		frame.Filename = location
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// lookupCallbackError returns the Go error returned by a callback from its host data handle.
+func lookupCallbackError(contextRef uint, handle uint) error {
+	muContexts.RLock()
+	ctx, ok := contexts[contextRef]
+	muContexts.RUnlock()
+	if !ok {
+		return nil
+	}
+	ctx.muHostData.RLock()
+	data := ctx.hostData[handle]
+	ctx.muHostData.RUnlock()
+	err, _ := data.(error)
 	return err
 }
 
+// Release releases the thrown values of the error and its causes.
+func (e *JSError) Release() {
+	for err := e; err != nil; err = err.Cause {
+		if err.Value != nil && err.Value.ctx.unregisterErrorValue(err.Value) {
+			err.Value.Release()
+		}
+		err.Value = nil
+	}
+}
+
 // Error returns the error message.
 func (e *JSError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the Go error returned by the callback or the module loader which has thrown the JS error, if any.
+//
+// The error cause is not part of the chain and is only available from the Cause field.
+func (e *JSError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether the error matches the target sentinel error.
+func (e *JSError) Is(target error) bool {
+	switch target {
+	case ErrInterrupted:
+		return e.kind == C.ERROR_KIND_INTERRUPTED
+	case ErrOutOfMemory:
+		return e.kind == C.ERROR_KIND_OUT_OF_MEMORY
+	case ErrStackOverflow:
+		return e.kind == C.ERROR_KIND_STACK_OVERFLOW
+	case ErrSyntax:
+		return e.kind == C.ERROR_KIND_SYNTAX
+	}
+	return false
+}
+
+// String returns the frame representation.
+func (f StackFrame) String() string {
+	return fmt.Sprintf("%s@%s:%d:%d", f.Function, f.Filename, f.LineNumber, f.ColumnNumber)
+}
+
 // Format implements fmt.Formatter.
 func (e *JSError) Format(f fmt.State, verb rune) {
 	switch verb {
@@ -46,5 +233,55 @@ func (e *JSError) Format(f fmt.State, verb rune) {
 	}
 }
 
+// CallbackError represents an error thrown as a JS error object by a Go callback.
+type CallbackError struct {
+	// Name is the error name. The standard names such as TypeError or RangeError select the error constructor.
+	Name string
+	// Message is the error message.
+	Message string
+	// Properties are the additional properties of the error object.
+	Properties map[string]any
+}
+
+// Error returns the error message.
+func (e *CallbackError) Error() string {
+	if e.Name == "" {
+		return e.Message
+	}
+	return e.Name + ": " + e.Message
+}
+
+// PanicError represents a panic recovered in a Go callback, thrown as a JS InternalError.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine which has panicked.
+	Stack []byte
+}
+
+// Error returns the error message.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// thrownValue implements an error throwing a JS value.
+type thrownValue struct {
+	v *Value
+}
+
+// Throw returns an error throwing the given JS value from a Go callback.
+//
+// The value is copied when thrown and remains owned by the caller.
+func Throw(value Valuer) error {
+	return &thrownValue{value.AsValue()}
+}
+
+// Error returns the error message.
+func (e *thrownValue) Error() string {
+	return "uncaught exception: " + e.v.String()
+}
+
 var _ error = (*JSError)(nil)
+var _ error = (*CallbackError)(nil)
+var _ error = (*PanicError)(nil)
 var _ fmt.Formatter = (*Value)(nil)
@@ -12,8 +12,34 @@ type Function struct {
 // FunctionCallback implements a JS function callback.
 type FunctionCallback func(args []*Value) (*Value, error)
 
+// withCallInfo returns the callback as a call information callback.
+func (cb FunctionCallback) withCallInfo() CallInfoCallback {
+	return func(info *CallInfo) (*Value, error) {
+		return cb(info.Args)
+	}
+}
+
+// CallInfo represents the information of a JS function call.
+//
+// The values are owned by the call and must not be released.
+type CallInfo struct {
+	Context   *Context
+	This      *Value
+	NewTarget *Value
+	Callee    *Function
+	Args      []*Value
+}
+
+// CallInfoCallback implements a JS function callback receiving the call information.
+type CallInfoCallback func(info *CallInfo) (*Value, error)
+
 // NewFunction creates a new JS function.
 func NewFunction(ctx *Context, name string, callback FunctionCallback) (*Function, error) {
+	return NewFunctionWithCallInfo(ctx, name, callback.withCallInfo())
+}
+
+// NewFunctionWithCallInfo creates a new JS function receiving the call information.
+func NewFunctionWithCallInfo(ctx *Context, name string, callback CallInfoCallback) (*Function, error) {
 	value, err := ctx.newFunction(name, callback)
 	if err != nil {
 		return nil, err
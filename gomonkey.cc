@@ -1,19 +1,39 @@
 #include "gomonkey.h"
 
+#include <jsfriendapi.h>
 #include <js/Array.h>
+#include <js/BuildId.h>
 #include <js/CompilationAndEvaluation.h>
 #include <js/Conversions.h>
+#include <js/Exception.h>
 #include <js/Initialization.h>
 #include <js/JSON.h>
 #include <js/MapAndSet.h>
+#include <js/Modules.h>
+#include <js/GCVector.h>
 #include <js/Object.h>
+#include <js/Promise.h>
+#include <js/ScriptPrivate.h>
 #include <js/SourceText.h>
-
+#include <js/Transcoding.h>
+#include <js/WeakMap.h>
+#include <js/experimental/JSStencil.h>
+#include <js/friend/ErrorMessages.h>
+
+#include <algorithm>
+#include <condition_variable>
+#include <cctype>
 #include <cstdint>
 #include <cstdlib>
+#include <deque>
+#include <functional>
+#include <mutex>
 #include <string>
+#include <thread>
 #include <vector>
 
+#include <mozilla/EndianUtils.h>
+
 #ifdef CGO
 #include "_cgo_export.h"
 #endif
@@ -22,20 +42,114 @@
  * Private objects.
  */
 
+class JobQueue : public JS::JobQueue {
+ public:
+  explicit JobQueue(JSContext *cx) : queue(cx) {}
+
+ private:
+  JobQueue(const JobQueue &) = delete;
+
+ public:
+  JSObject *getIncumbentGlobal(JSContext *cx) override {
+    return JS::CurrentGlobalOrNull(cx);
+  }
+  bool enqueuePromiseJob(JSContext *cx, JS::HandleObject, JS::HandleObject job,
+                         JS::HandleObject, JS::HandleObject) override {
+    if (!queue.append(job)) {
+      JS_ReportOutOfMemory(cx);
+      return false;
+    }
+    JS::JobQueueMayNotBeEmpty(cx);
+    return true;
+  }
+  void runJobs(JSContext *cx) override { drain(cx); }
+  bool empty() const override { return queue.empty(); }
+  bool drain(JSContext *cx) {
+    if (draining) {
+      return true;
+    }
+    draining = true;
+
+    JS::RootedObject job(cx);
+    JS::RootedValue rval(cx);
+    for (size_t i = 0; i < queue.length(); i++) {
+      job = queue[i];
+
+      JSAutoRealm ar(cx, job);
+      if (!JS::Call(cx, JS::UndefinedHandleValue, job,
+                    JS::HandleValueArray::empty(), &rval)) {
+        queue.erase(queue.begin(), queue.begin() + i + 1);
+        draining = false;
+        return false;
+      }
+    }
+    queue.clear();
+    JS::JobQueueIsEmpty(cx);
+
+    draining = false;
+    return true;
+  }
+
+ private:
+  JobQueue &operator=(const JobQueue &) = delete;
+
+ private:
+  class SavedQueue : public SavedJobQueue {
+   public:
+    explicit SavedQueue(JSContext *cx, JobQueue *jobQueue)
+        : jobQueue(jobQueue),
+          saved(cx, std::move(jobQueue->queue.get())),
+          draining(jobQueue->draining) {
+      jobQueue->queue.get().clear();
+      jobQueue->draining = false;
+    }
+    ~SavedQueue() {
+      jobQueue->queue = std::move(saved.get());
+      jobQueue->draining = draining;
+    }
+
+   private:
+    JobQueue *jobQueue;
+    JS::PersistentRooted<JS::GCVector<JSObject *, 0, js::SystemAllocPolicy>>
+        saved;
+    bool draining;
+  };
+
+  js::UniquePtr<SavedJobQueue> saveJobQueue(JSContext *cx) override {
+    auto saved = js::MakeUnique<SavedQueue>(cx, this);
+    if (!saved) {
+      JS_ReportOutOfMemory(cx);
+      return nullptr;
+    }
+    return saved;
+  }
+
+ private:
+  JS::PersistentRooted<JS::GCVector<JSObject *, 0, js::SystemAllocPolicy>>
+      queue;
+  bool draining = false;
+};
+
 class Context {
  public:
   enum class Slots : uint8_t {
     REF,
+    ERRORS,
     SLOT_COUNT,
   };
 
  public:
-  explicit Context(unsigned ref, JSContext *cx, JS::HandleObject global)
-      : ref(ref), ptr(cx), globalPtr(global) {
+  explicit Context(unsigned ref, JSContext *cx, JS::HandleObject global,
+                   JobQueue *jobQueue)
+      : ref(ref), ptr(cx), globalPtr(global), jobQueue(jobQueue) {
     if (globalPtr) JS_AddExtraGCRootsTracer(ptr, traceGlobal, &globalPtr);
+    JS_AddExtraGCRootsTracer(ptr, traceException, this);
   }
   ~Context() {
     if (globalPtr) JS_RemoveExtraGCRootsTracer(ptr, traceGlobal, &globalPtr);
+    JS_RemoveExtraGCRootsTracer(ptr, traceException, this);
+    JS::SetJobQueue(ptr, nullptr);
+    delete jobQueue;
   }
 
  private:
@@ -45,6 +159,28 @@ class Context {
   unsigned getRef() const { return ref; }
   JSContext *getJSContext() const { return ptr; }
   JSObject *getGlobalJSObject() const { return globalPtr; };
+  JobQueue *getJobQueue() const { return jobQueue; };
+  JS::Value getException() const { return exception; };
+  JSObject *getExceptionStack() const { return exceptionStack; };
+  void setException(JS::HandleValue value, JS::HandleObject stack) {
+    exception = value;
+    exceptionStack = stack;
+  };
+  void clearException() {
+    exception = JS::UndefinedValue();
+    exceptionStack = nullptr;
+  };
+  void setInterruptException(JS::HandleValue value) {
+    interruptException = value;
+  };
+  bool takeInterruptException(JS::HandleValue value) {
+    bool interrupted =
+        !interruptException.get().isUndefined() && interruptException == value;
+    if (interrupted) {
+      interruptException = JS::UndefinedValue();
+    }
+    return interrupted;
+  };
 
  private:
   Context &operator=(const Context &) = delete;
@@ -53,11 +189,21 @@ class Context {
   static void traceGlobal(JSTracer *trc, void *data) {
     JS::TraceEdge(trc, (JS::Heap<JSObject *> *)data, "global");
   }
+  static void traceException(JSTracer *trc, void *data) {
+    Context *ctx = static_cast<Context *>(data);
+    JS::TraceEdge(trc, &ctx->exception, "exception");
+    JS::TraceEdge(trc, &ctx->exceptionStack, "exception stack");
+    JS::TraceEdge(trc, &ctx->interruptException, "interrupt exception");
+  }
 
  private:
   unsigned ref;
   JSContext *ptr;
   JS::Heap<JSObject *> globalPtr;
+  JobQueue *jobQueue;
+  JS::Heap<JS::Value> exception;
+  JS::Heap<JSObject *> exceptionStack;
+  JS::Heap<JS::Value> interruptException;
 };
 
 class Script {
@@ -91,6 +237,79 @@ class Script {
   JS::Heap<JSScript *> ptr;
 };
 
+class Module {
+ public:
+  explicit Module(Context *ctx, JS::HandleObject module)
+      : ctx(ctx), ptr(module) {
+    if (ptr) JS_AddExtraGCRootsTracer(ctx->getJSContext(), traceModule, &ptr);
+  };
+  ~Module() {
+    if (ptr)
+      JS_RemoveExtraGCRootsTracer(ctx->getJSContext(), traceModule, &ptr);
+  }
+
+ private:
+  Module(const Module &) = delete;
+
+ public:
+  Context *getContext() const { return ctx; };
+  JSObject *getJSObject() const { return ptr.get(); };
+
+ private:
+  Module &operator=(const Module &) = delete;
+
+ private:
+  static void traceModule(JSTracer *trc, void *data) {
+    JS::TraceEdge(trc, (JS::Heap<JSObject *> *)data, "module");
+  }
+
+ private:
+  Context *ctx;
+  JS::Heap<JSObject *> ptr;
+};
+
+class DynamicImport {
+ public:
+  explicit DynamicImport(Context *ctx, JS::HandleValue referencingPrivate,
+                         JS::HandleObject moduleRequest,
+                         JS::HandleObject promise)
+      : ctx(ctx),
+        referencingPrivate(referencingPrivate),
+        moduleRequest(moduleRequest),
+        promise(promise) {
+    JS_AddExtraGCRootsTracer(ctx->getJSContext(), traceDynamicImport, this);
+  };
+  ~DynamicImport() {
+    JS_RemoveExtraGCRootsTracer(ctx->getJSContext(), traceDynamicImport, this);
+  }
+
+ private:
+  DynamicImport(const DynamicImport &) = delete;
+
+ public:
+  Context *getContext() const { return ctx; };
+  JS::Value getReferencingPrivate() const { return referencingPrivate.get(); };
+  JSObject *getModuleRequest() const { return moduleRequest.get(); };
+  JSObject *getPromise() const { return promise.get(); };
+
+ private:
+  DynamicImport &operator=(const DynamicImport &) = delete;
+
+ private:
+  static void traceDynamicImport(JSTracer *trc, void *data) {
+    DynamicImport *imp = static_cast<DynamicImport *>(data);
+    JS::TraceEdge(trc, &imp->referencingPrivate, "referencingPrivate");
+    JS::TraceEdge(trc, &imp->moduleRequest, "moduleRequest");
+    JS::TraceEdge(trc, &imp->promise, "promise");
+  }
+
+ private:
+  Context *ctx;
+  JS::Heap<JS::Value> referencingPrivate;
+  JS::Heap<JSObject *> moduleRequest;
+  JS::Heap<JSObject *> promise;
+};
+
 class Value {
  public:
   explicit Value(Context *ctx, JS::HandleValue value) : ctx(ctx), ptr(value) {
@@ -122,263 +341,1725 @@ class Value {
 
 class FrontendContext {
  public:
-  explicit FrontendContext(JS::FrontendContext *cx) : ptr(cx) {}
+  explicit FrontendContext(size_t stackSize) : stackSize(stackSize) {}
 
  private:
   FrontendContext(const FrontendContext &c) = delete;
 
  public:
-  JS::FrontendContext *getJSFrontendContext() const { return ptr; }
+  size_t getStackSize() const { return stackSize; }
 
  private:
   FrontendContext &operator=(const FrontendContext &) = delete;
 
  private:
-  JS::FrontendContext *ptr;
+  size_t stackSize;
 };
 
 class Stencil {
  public:
-  explicit Stencil(RefPtr<JS::Stencil> stencil) : ptr(stencil){};
+  explicit Stencil(RefPtr<JS::Stencil> stencil, bool module = false)
+      : ptr(stencil), module(module){};
 
  public:
   JS::Stencil *getStencil() const { return ptr.get(); }
+  bool isModule() const { return module; }
 
  private:
   RefPtr<JS::Stencil> ptr;
+  bool module;
 };
 
-/*
- * Go callbacks.
- */
+static JSObject *CreateGlobalObject(JSContext *cx, bool resolve);
 
-extern ContextPtr goFunctionContext(unsigned contextRef);
+// StencilWorkerStackQuota is the native stack quota of the stencil worker,
+// below the smallest default stack size of the secondary threads.
+static const size_t StencilWorkerStackQuota = 256 * 1024;
 
-extern ResultGoFunctionCallback goFunctionCallback(unsigned contextRef,
-                                                   char *name, unsigned argc,
-                                                   ValuePtr *vp);
+// StencilWorker runs the stencil tasks requiring a JS context, compilations and
+// encodings, in a context bound to a dedicated thread since a thread can only
+// have one context. It is started on first use and stopped at shutdown.
+class StencilWorker {
+ public:
+  typedef std::function<bool(JSContext *)> Task;
 
-/*
- * Private functions.
- */
+  StencilWorker() = default;
+  ~StencilWorker() {
+    if (thread.joinable()) {
+      thread.detach();
+    }
+  }
 
-static JSObject *CreateGlobalObject(JSContext *cx) {
-  JS::RealmOptions options;
-  static JSClass GlobalClass = {"Global",
-                                JSCLASS_GLOBAL_FLAGS_WITH_SLOTS(1),
-                                &JS::DefaultGlobalClassOps,
-                                nullptr,
-                                nullptr,
-                                nullptr};
+ private:
+  StencilWorker(const StencilWorker &) = delete;
 
-  return JS_NewGlobalObject(cx, &GlobalClass, nullptr, JS::FireOnNewGlobalHook,
-                            options);
-}
+ public:
+  bool run(const Task &task) {
+    std::unique_lock<std::mutex> lock(mutex);
+    if (stopping) {
+      return false;
+    }
+    if (!thread.joinable()) {
+      thread = std::thread(&StencilWorker::loop, this);
+    }
 
-static Error GetError(JSContext *cx) {
-  Error err = {};
+    Request request = {&task, false, false};
+    requests.push_back(&request);
+    cond.notify_all();
+    cond.wait(lock, [&] { return request.done; });
+    return request.ok;
+  };
+  void stop() {
+    std::unique_lock<std::mutex> lock(mutex);
+    if (!thread.joinable()) {
+      return;
+    }
+    stopping = true;
+    cond.notify_all();
+    lock.unlock();
+    thread.join();
+    lock.lock();
+    stopping = false;
+  };
 
-  JS::ExceptionStack stack(cx);
-  if (!JS::StealPendingExceptionStack(cx, &stack)) {
-    return err;
-  }
-  JS::ErrorReportBuilder builder(cx);
-  if (!builder.init(cx, stack, JS::ErrorReportBuilder::WithSideEffects)) {
-    return err;
+ private:
+  StencilWorker &operator=(const StencilWorker &) = delete;
+
+ private:
+  struct Request {
+    const Task *task;
+    bool ok;
+    bool done;
+  };
+
+  void loop() {
+    JSContext *cx = JS_NewContext(JS::DefaultHeapMaxBytes);
+    if (cx && JS::InitSelfHostedCode(cx)) {
+      JS_SetNativeStackQuota(cx, StencilWorkerStackQuota);
+      serve(cx);
+    } else {
+      serve(nullptr);
+    }
+    if (cx) {
+      JS_DestroyContext(cx);
+    }
   }
-  JSErrorReport *report = builder.report();
 
-  char *message = strdup(report->message().c_str());
-  if (!message) {
-    return err;
+  // serve runs the tasks in the realm of a global object, which holds the
+  // errors of the compilations.
+  void serve(JSContext *cx) {
+    mozilla::Maybe<JS::RootedObject> global;
+    mozilla::Maybe<JSAutoRealm> ar;
+    if (cx) {
+      global.emplace(cx, CreateGlobalObject(cx, false));
+      if (*global) {
+        ar.emplace(cx, *global);
+      }
+    }
+    bool ready = ar.isSome();
+
+    std::unique_lock<std::mutex> lock(mutex);
+    for (;;) {
+      cond.wait(lock, [&] { return stopping || !requests.empty(); });
+      if (requests.empty()) {
+        break;
+      }
+      Request *request = requests.front();
+      requests.pop_front();
+      lock.unlock();
+      request->ok = ready && (*request->task)(cx);
+      lock.lock();
+      request->done = true;
+      cond.notify_all();
+    }
   }
 
-  err.message = message;
-  err.filename = report->filename;
-  err.lineno = report->lineno;
-  err.number = report->errorNumber;
-  return err;
-}
+ private:
+  std::mutex mutex;
+  std::condition_variable cond;
+  std::deque<Request *> requests;
+  std::thread thread;
+  bool stopping = false;
+};
 
-static bool InterruptCallback(JSContext *cx) {
-  JS_ResetInterruptCallback(cx, true);
+static StencilWorker stencilWorker;
 
-  JS_ReportErrorUTF8(cx, "Execution interrupted");
+/*
+ * Go callbacks.
+ */
 
-  return false;
-}
+extern ContextPtr goFunctionContext(unsigned contextRef);
 
-static bool FunctionCallback(JSContext *cx, unsigned argc, JS::Value *vp) {
-  JS::CallArgs args = JS::CallArgsFromVp(argc, vp);
+extern ResultGoFunctionCallback goFunctionCallback(
+    unsigned contextRef, unsigned handle, ValuePtr thisv, ValuePtr newTarget,
+    ValuePtr callee, unsigned argc, ValuePtr *vp);
 
-  JS::RootedValue funcVal(cx, args.calleev());
-  JSFunction *func = JS_ValueToFunction(cx, funcVal);
-  if (!func) {
-    JS_ReportOutOfMemory(cx);
-    return false;
-  }
+extern void goFunctionFinalize(unsigned contextRef, unsigned handle);
 
-  JS::PersistentRootedObject global(cx,
-                                    JS::GetNonCCWObjectGlobal(&args.callee()));
-  if (!global) {
-    JS_ReportOutOfMemory(cx);
+extern void goHostObjectFinalize(unsigned contextRef, unsigned handle);
+
+extern ResultGoModuleResolve goModuleResolve(unsigned contextRef,
+                                             char *referrer, char *specifier);
+
+extern char *goModuleDynamicImport(unsigned contextRef, DynamicImportPtr imp,
+                                   char *referrer, char *specifier);
+
+extern char *goModuleMetadata(unsigned contextRef, char *name, ValuePtr meta);
+
+extern char *goGlobalResolve(unsigned contextRef, char *name, ValuePtr global);
+
+/*
+ * Private functions.
+ */
+
+static bool GlobalMayResolve(const JSAtomState &names, jsid id,
+                             JSObject *maybeObj) {
+  return id.isString() || JS_MayResolveStandardClass(names, id, maybeObj);
+}
+
+static bool GlobalResolve(JSContext *cx, JS::HandleObject obj, JS::HandleId id,
+                          bool *resolvedp) {
+  if (!JS_ResolveStandardClass(cx, obj, id, resolvedp)) {
     return false;
   }
+  if (*resolvedp || !id.isString()) {
+    return true;
+  }
+
   JS::RootedValue contextRefVal(
-      cx,
-      JS::GetReservedSlot(global, static_cast<size_t>(Context::Slots::REF)));
+      cx, JS::GetReservedSlot(obj, static_cast<size_t>(Context::Slots::REF)));
   if (!contextRefVal.isInt32()) {
-    JS_ReportOutOfMemory(cx);
-    return false;
+    return true;
   }
-  unsigned contextRef = contextRefVal.toInt32();
-
+  unsigned contextRef = contextRefVal.toPrivateUint32();
   ContextPtr ctx = goFunctionContext(contextRef);
   if (!ctx) {
-    JS_ReportOutOfMemory(cx);
-    return false;
+    return true;
   }
 
-  JSString *nameStr = JS_GetFunctionId(func);
-  if (!nameStr) {
-    JS_ReportOutOfMemory(cx);
-    return false;
-  }
-  size_t len = JS_GetStringEncodingLength(cx, nameStr);
-  char *name = static_cast<char *>(JS_malloc(cx, len + 1));
+  JS::RootedString str(cx, id.toString());
+  JS::UniqueChars name = JS_EncodeStringToUTF8(cx, str);
   if (!name) {
-    JS_ReportOutOfMemory(cx);
     return false;
   }
-  if (!JS_EncodeStringToBuffer(cx, nameStr, name, len)) {
+
+  static thread_local std::vector<std::string> resolving;
+  if (std::find(resolving.begin(), resolving.end(), name.get()) !=
+      resolving.end()) {
+    return true;
+  }
+
+  JS::RootedValue globalVal(cx, JS::ObjectValue(*obj));
+  Value *global = new Value(ctx, globalVal);
+  if (!global) {
     JS_ReportOutOfMemory(cx);
-    JS_free(cx, name);
     return false;
   }
-  name[len] = 0;
 
-  std::vector<Value *> vals = {};
-  for (unsigned i = 0; i < args.length(); i++) {
-    Value *v = new Value(ctx, args.get(i));
-    if (!v) {
-      JS_ReportOutOfMemory(cx);
-      return false;
-    }
-    vals.push_back(v);
+  resolving.push_back(name.get());
+  char *err = goGlobalResolve(contextRef, name.get(), global);
+  resolving.pop_back();
+  delete global;
+  if (err) {
+    JS_ReportErrorUTF8(cx, "%s", err);
+    JS_free(cx, err);
+    return false;
   }
-  JS::RootedValue rval(cx);
 
-  ResultGoFunctionCallback result =
-      goFunctionCallback(contextRef, name, vals.size(), vals.data());
+  return JS_AlreadyHasOwnPropertyById(cx, obj, id, resolvedp);
+}
 
-  for (const auto &val : vals) {
-    delete val;
+static JSObject *CreateGlobalObject(JSContext *cx, bool resolve) {
+  JS::RealmOptions options;
+  static JSClass GlobalClass = {
+      "Global",
+      JSCLASS_GLOBAL_FLAGS_WITH_SLOTS(
+          static_cast<uint32_t>(Context::Slots::SLOT_COUNT)),
+      &JS::DefaultGlobalClassOps,
+      nullptr,
+      nullptr,
+      nullptr};
+  static const JSClassOps GlobalResolveClassOps = {
+      nullptr,                        // addProperty
+      nullptr,                        // delProperty
+      nullptr,                        // enumerate
+      JS_NewEnumerateStandardClasses, // newEnumerate
+      GlobalResolve,                  // resolve
+      GlobalMayResolve,               // mayResolve
+      nullptr,                        // finalize
+      nullptr,                        // call
+      nullptr,                        // construct
+      JS_GlobalObjectTraceHook,       // trace
+  };
+  static JSClass GlobalResolveClass = {
+      "Global",
+      JSCLASS_GLOBAL_FLAGS_WITH_SLOTS(
+          static_cast<uint32_t>(Context::Slots::SLOT_COUNT)),
+      &GlobalResolveClassOps,
+      nullptr,
+      nullptr,
+      nullptr};
+
+  return JS_NewGlobalObject(cx, resolve ? &GlobalResolveClass : &GlobalClass,
+                            nullptr, JS::FireOnNewGlobalHook, options);
+}
+
+static const char *GetErrorName(int16_t exnType) {
+  switch (exnType) {
+    case JSEXN_INTERNALERR:
+      return "InternalError";
+    case JSEXN_AGGREGATEERR:
+      return "AggregateError";
+    case JSEXN_EVALERR:
+      return "EvalError";
+    case JSEXN_RANGEERR:
+      return "RangeError";
+    case JSEXN_REFERENCEERR:
+      return "ReferenceError";
+    case JSEXN_SYNTAXERR:
+      return "SyntaxError";
+    case JSEXN_TYPEERR:
+      return "TypeError";
+    case JSEXN_URIERR:
+      return "URIError";
+    default:
+      return "Error";
+  }
+}
+
+static JSExnType GetErrorType(const char *name) {
+  for (int16_t exnType = JSEXN_ERR; exnType <= JSEXN_URIERR; exnType++) {
+    if (strcmp(GetErrorName(exnType), name) == 0) {
+      return static_cast<JSExnType>(exnType);
+    }
+  }
+  return JSEXN_ERR;
+}
+
+static const JSErrorFormatString *GetErrorFormat(void *,
+                                                 const unsigned errorNumber) {
+  static const JSErrorFormatString formats[] = {
+      {"GoError", "{0}", 1, JSEXN_ERR},
+      {"GoInternalError", "{0}", 1, JSEXN_INTERNALERR},
+      {"GoAggregateError", "{0}", 1, JSEXN_AGGREGATEERR},
+      {"GoEvalError", "{0}", 1, JSEXN_EVALERR},
+      {"GoRangeError", "{0}", 1, JSEXN_RANGEERR},
+      {"GoReferenceError", "{0}", 1, JSEXN_REFERENCEERR},
+      {"GoSyntaxError", "{0}", 1, JSEXN_SYNTAXERR},
+      {"GoTypeError", "{0}", 1, JSEXN_TYPEERR},
+      {"GoURIError", "{0}", 1, JSEXN_URIERR},
+  };
+  if (errorNumber >= std::size(formats)) {
+    return nullptr;
   }
-  JS_free(cx, name);
+  return &formats[errorNumber];
+}
 
-  if (result.err) {
-    JS_ReportErrorUTF8(cx, "%s", result.err);
-    JS_free(cx, result.err);
-    return false;
+static void SetErrorProperties(JSContext *cx, const char *name,
+                               JS::HandleValue props) {
+  JS::RootedValue exception(cx);
+  if (!JS_GetPendingException(cx, &exception) || !exception.isObject()) {
+    return;
   }
-  if (result.ptr) {
-    rval.set(result.ptr->getJSValue());
+  bool customName = name && GetErrorType(name) == JSEXN_ERR &&
+                    strcmp(name, GetErrorName(JSEXN_ERR)) != 0;
+  if (!customName && !props.isObject()) {
+    return;
+  }
+  JS_ClearPendingException(cx);
 
-    delete result.ptr;
-  } else {
-    rval.setUndefined();
+  JS::RootedObject errorObj(cx, &exception.toObject());
+  if (customName) {
+    JS::RootedString str(
+        cx, JS_NewStringCopyUTF8Z(cx, JS::ConstUTF8CharsZ(name, strlen(name))));
+    if (!str || !JS_DefineProperty(cx, errorObj, "name", str, 0)) {
+      return;
+    }
+  }
+  if (props.isObject()) {
+    JS::RootedObject propsObj(cx, &props.toObject());
+    if (!JS_AssignObject(cx, errorObj, propsObj)) {
+      return;
+    }
   }
 
-  args.rval().set(rval);
-  return true;
+  JS_SetPendingException(cx, exception);
 }
 
-static bool StringifyCallback(const char16_t *buf, uint32_t len, void *data) {
-  std::u16string *str = static_cast<std::u16string *>(data);
-  str->append(buf, len);
+static void GetErrorHandle(JSContext *cx, JS::HandleValue exception,
+                           Error *err);
+
+static bool SetErrorReport(Error *err, JSErrorReport *report) {
+  char *message = strdup(report->message().c_str());
+  if (!message) {
+    return false;
+  }
+
+  err->message = message;
+  err->filename = report->filename;
+  err->lineno = report->lineno;
+  err->column = report->column + 1;
+  err->number = report->errorNumber;
+  err->name = GetErrorName(report->exnType);
   return true;
 }
 
-/*
- * Public functions.
- */
+static char *GetStackString(JSContext *cx, JS::HandleObject stack) {
+  if (!stack) {
+    return nullptr;
+  }
+  JS::RootedString str(cx);
+  if (!JS::BuildStackString(cx, nullptr, stack, &str)) {
+    JS_ClearPendingException(cx);
+    return nullptr;
+  }
+  JS::UniqueChars chars = JS_EncodeStringToUTF8(cx, str);
+  if (!chars) {
+    JS_ClearPendingException(cx);
+    return nullptr;
+  }
+  return strdup(chars.get());
+}
 
-bool Init() { return JS_Init(); }
+// GetTokenLength returns the length of the identifier, keyword or number at
+// the start of the characters, or 1 for a punctuator.
+static size_t GetTokenLength(const char16_t *chars, size_t length) {
+  size_t n = 1;
+  if (JS_IsIdentifier(chars, 1)) {
+    while (n < length && JS_IsIdentifier(chars, n + 1)) {
+      n++;
+    }
+  } else if (chars[0] >= '0' && chars[0] <= '9') {
+    while (n < length && chars[n] < 128 &&
+           (isalnum(chars[n]) || chars[n] == '.' || chars[n] == '_')) {
+      n++;
+    }
+  }
+  return n;
+}
 
-void ShutDown() { JS_ShutDown(); }
+// GetError takes the pending exception of the context. The syntax errors are
+// only identified when compiling, with the length of their token if requested.
+static Error GetError(JSContext *cx, bool compiling = false,
+                      size_t *tokenLength = nullptr) {
+  Error err = {};
 
-const char *Version() {
-  const std::string version = std::to_string(MOZJS_MAJOR_VERSION) + "." +
-                              std::to_string(MOZJS_MINOR_VERSION);
+  bool outOfMemory = JS_IsThrowingOutOfMemory(cx);
+  JS::ExceptionStack stack(cx);
+  if (!JS::StealPendingExceptionStack(cx, &stack)) {
+    return err;
+  }
+  GetErrorHandle(cx, stack.exception(), &err);
 
-  char *str = strdup(version.c_str());
+  // the exception is kept by the context until it is taken by the caller
+  Context *ctx = static_cast<Context *>(JS_GetContextPrivate(cx));
+  if (ctx) {
+    ctx->setException(stack.exception(), stack.stack());
+    err.contextRef = ctx->getRef();
+    err.exception = true;
+  }
+  if (outOfMemory) {
+    err.kind = ERROR_KIND_OUT_OF_MEMORY;
+  } else if (ctx && ctx->takeInterruptException(stack.exception())) {
+    err.kind = ERROR_KIND_INTERRUPTED;
+  }
 
-  return str;
+  JS::ErrorReportBuilder builder(cx);
+  if (!builder.init(cx, stack, JS::ErrorReportBuilder::WithSideEffects)) {
+    return err;
+  }
+  JSErrorReport *report = builder.report();
+  if (err.kind == ERROR_KIND_DEFAULT &&
+      report->errorNumber == JSMSG_OVER_RECURSED) {
+    err.kind = ERROR_KIND_STACK_OVERFLOW;
+  } else if (err.kind == ERROR_KIND_DEFAULT && compiling &&
+             report->exnType == JSEXN_SYNTAXERR) {
+    err.kind = ERROR_KIND_SYNTAX;
+  }
+  if (tokenLength && report->linebuf() &&
+      report->tokenOffset() < report->linebufLength()) {
+    *tokenLength =
+        GetTokenLength(report->linebuf() + report->tokenOffset(),
+                       report->linebufLength() - report->tokenOffset());
+  }
+  SetErrorReport(&err, report);
+  return err;
 }
 
-ContextPtr NewContext(unsigned ref, ContextOptions options) {
-  uint32_t heapMaxBytes = JS::DefaultHeapMaxBytes;
-  if (options.heapMaxBytes) {
-    heapMaxBytes = options.heapMaxBytes;
-  }
+// StencilFormatVersion is the version of the stencil encoding of the bindings,
+// increased when the encoded stencils become incompatible.
+static const int StencilFormatVersion = 2;
 
-  JSContext *cx = JS_NewContext(heapMaxBytes);
-  if (!cx) {
-    return nullptr;
-  }
-  if (options.stackSize) {
-    JS_SetNativeStackQuota(cx, options.stackSize);
-  }
-#ifdef DEBUG
-  JS_SetGCZeal(cx, 14, 1);
+// GetBuildId returns the identifier of the engine version and of the build
+// configuration affecting the encoded stencils.
+static std::string GetBuildId() {
+  std::string buildId = JS_GetImplementationVersion();
+  buildId += ";gomonkey-stencil-" + std::to_string(StencilFormatVersion);
+#ifdef JS_DEBUG
+  buildId += ";debug";
+#endif
+#ifdef JS_64BIT
+  buildId += ";64bit";
+#endif
+#ifdef JS_PUNBOX64
+  buildId += ";punbox64";
+#endif
+#ifdef JS_NUNBOX32
+  buildId += ";nunbox32";
+#endif
+#ifdef JS_HAS_INTL_API
+  buildId += ";intl";
+#endif
+#ifdef JS_GC_ZEAL
+  buildId += ";gczeal";
+#endif
+#ifdef JS_ENABLE_SMOOSH
+  buildId += ";smoosh";
+#endif
+#ifdef NIGHTLY_BUILD
+  buildId += ";nightly";
+#endif
+#if MOZ_LITTLE_ENDIAN()
+  buildId += ";le";
+#else
+  buildId += ";be";
+#endif
+  return buildId;
+}
+
+static bool BuildIdOp(JS::BuildIdCharVector *buildId) {
+  const std::string id = GetBuildId();
+  return buildId->append(id.data(), id.size());
+}
+
+static bool InterruptCallback(JSContext *cx) {
+  JS_ResetInterruptCallback(cx, true);
+
+  JS_ReportErrorUTF8(cx, "Execution interrupted");
+
+  Context *ctx = static_cast<Context *>(JS_GetContextPrivate(cx));
+  if (ctx) {
+    JS::RootedValue exception(cx);
+    if (JS_GetPendingException(cx, &exception)) {
+      ctx->setInterruptException(exception);
+    }
+  }
+
+  return false;
+}
+
+enum class FunctionHandleSlots : uint8_t {
+  CONTEXT_REF,
+  HANDLE,
+  SLOT_COUNT,
+};
+
+static void FunctionHandleFinalize(JS::GCContext *, JSObject *obj) {
+  JS::Value contextRefVal = JS::GetReservedSlot(
+      obj, static_cast<size_t>(FunctionHandleSlots::CONTEXT_REF));
+  JS::Value handleVal =
+      JS::GetReservedSlot(obj, static_cast<size_t>(FunctionHandleSlots::HANDLE));
+  if (!contextRefVal.isInt32() || !handleVal.isInt32()) {
+    return;
+  }
+
+  goFunctionFinalize(contextRefVal.toPrivateUint32(),
+                     handleVal.toPrivateUint32());
+}
+
+static const JSClassOps FunctionHandleClassOps = {
+    nullptr, nullptr, nullptr, nullptr, nullptr,
+    nullptr, FunctionHandleFinalize, nullptr, nullptr, nullptr,
+};
+
+static const JSClass FunctionHandleClass = {
+    "FunctionHandle",
+    JSCLASS_HAS_RESERVED_SLOTS(
+        static_cast<uint32_t>(FunctionHandleSlots::SLOT_COUNT)) |
+        JSCLASS_FOREGROUND_FINALIZE,
+    &FunctionHandleClassOps,
+    nullptr,
+    nullptr,
+    nullptr,
+};
+
+static bool SetFunctionHandle(JSContext *cx, JSObject *funcObj,
+                              unsigned contextRef, unsigned handle) {
+  JSObject *handleObj = JS_NewObject(cx, &FunctionHandleClass);
+  if (!handleObj) {
+    return false;
+  }
+  JS_SetReservedSlot(handleObj,
+                     static_cast<uint32_t>(FunctionHandleSlots::CONTEXT_REF),
+                     JS::PrivateUint32Value(contextRef));
+  JS_SetReservedSlot(handleObj,
+                     static_cast<uint32_t>(FunctionHandleSlots::HANDLE),
+                     JS::PrivateUint32Value(handle));
+  js::SetFunctionNativeReserved(funcObj, 0, JS::ObjectValue(*handleObj));
+  return true;
+}
+
+enum class HostObjectSlots : uint8_t {
+  CONTEXT_REF,
+  HANDLE,
+  CACHE,
+  SLOT_COUNT,
+};
+
+static void HostObjectFinalize(JS::GCContext *, JSObject *obj) {
+  JS::Value contextRefVal = JS::GetReservedSlot(
+      obj, static_cast<size_t>(HostObjectSlots::CONTEXT_REF));
+  JS::Value handleVal =
+      JS::GetReservedSlot(obj, static_cast<size_t>(HostObjectSlots::HANDLE));
+  if (!contextRefVal.isInt32() || !handleVal.isInt32()) {
+    return;
+  }
+
+  goHostObjectFinalize(contextRefVal.toPrivateUint32(),
+                       handleVal.toPrivateUint32());
+}
+
+static const JSClassOps HostObjectClassOps = {
+    nullptr, nullptr, nullptr, nullptr, nullptr,
+    nullptr, HostObjectFinalize, nullptr, nullptr, nullptr,
+};
+
+static const JSClass HostObjectClass = {
+    "Object",
+    JSCLASS_HAS_RESERVED_SLOTS(
+        static_cast<uint32_t>(HostObjectSlots::SLOT_COUNT)) |
+        JSCLASS_FOREGROUND_FINALIZE,
+    &HostObjectClassOps,
+    nullptr,
+    nullptr,
+    nullptr,
+};
+
+static JSObject *GetErrorHandles(JSContext *cx, bool create) {
+  JS::RootedObject global(cx);
+  global = JS::CurrentGlobalOrNull(cx);
+  if (!global) {
+    return nullptr;
+  }
+  JS::Value errorsVal = JS::GetReservedSlot(
+      global, static_cast<size_t>(Context::Slots::ERRORS));
+  if (errorsVal.isObject()) {
+    return &errorsVal.toObject();
+  }
+  if (!create) {
+    return nullptr;
+  }
+
+  JSObject *errors = JS::NewWeakMapObject(cx);
+  if (!errors) {
+    return nullptr;
+  }
+  JS_SetReservedSlot(global, static_cast<uint32_t>(Context::Slots::ERRORS),
+                     JS::ObjectValue(*errors));
+  return errors;
+}
+
+static void GetErrorHandle(JSContext *cx, JS::HandleValue exception,
+                           Error *err) {
+  if (!exception.isObject()) {
+    return;
+  }
+  JS::RootedObject errors(cx);
+  errors = GetErrorHandles(cx, false);
+  if (!errors) {
+    return;
+  }
+
+  JS::RootedObject errorObj(cx, &exception.toObject());
+  JS::RootedValue handleVal(cx);
+  if (!JS::GetWeakMapEntry(cx, errors, errorObj, &handleVal)) {
+    JS_ClearPendingException(cx);
+    return;
+  }
+  if (!handleVal.isObject() ||
+      JS::GetClass(&handleVal.toObject()) != &HostObjectClass) {
+    return;
+  }
+
+  JSObject *handleObj = &handleVal.toObject();
+  JS::Value contextRefVal = JS::GetReservedSlot(
+      handleObj, static_cast<size_t>(HostObjectSlots::CONTEXT_REF));
+  JS::Value handleSlotVal = JS::GetReservedSlot(
+      handleObj, static_cast<size_t>(HostObjectSlots::HANDLE));
+  if (!contextRefVal.isInt32() || !handleSlotVal.isInt32()) {
+    return;
+  }
+  err->contextRef = contextRefVal.toPrivateUint32();
+  err->handle = handleSlotVal.toPrivateUint32();
+}
+
+static void SetErrorHandle(JSContext *cx, unsigned contextRef,
+                           unsigned handle) {
+  JS::ExceptionStack stack(cx);
+  if (!JS::StealPendingExceptionStack(cx, &stack)) {
+    goHostObjectFinalize(contextRef, handle);
+    return;
+  }
+
+  JS::RootedObject errors(cx);
+  JS::RootedObject handleObj(cx);
+  if (stack.exception().isObject()) {
+    errors = GetErrorHandles(cx, true);
+  }
+  if (errors) {
+    handleObj = JS_NewObject(cx, &HostObjectClass);
+  }
+  if (handleObj) {
+    JS_SetReservedSlot(handleObj,
+                       static_cast<uint32_t>(HostObjectSlots::CONTEXT_REF),
+                       JS::PrivateUint32Value(contextRef));
+    JS_SetReservedSlot(handleObj,
+                       static_cast<uint32_t>(HostObjectSlots::HANDLE),
+                       JS::PrivateUint32Value(handle));
+    JS::RootedObject errorObj(cx, &stack.exception().toObject());
+    JS::RootedValue handleVal(cx, JS::ObjectValue(*handleObj));
+    JS::SetWeakMapEntry(cx, errors, errorObj, handleVal);
+  } else {
+    goHostObjectFinalize(contextRef, handle);
+  }
+
+  JS_ClearPendingException(cx);
+  JS::SetPendingExceptionStack(cx, stack);
+}
+
+static bool FunctionCallback(JSContext *cx, unsigned argc, JS::Value *vp) {
+  JS::CallArgs args = JS::CallArgsFromVp(argc, vp);
+
+  JS::RootedValue funcVal(cx, args.calleev());
+  JSFunction *func = JS_ValueToFunction(cx, funcVal);
+  if (!func) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+
+  JS::PersistentRootedObject global(cx,
+                                    JS::GetNonCCWObjectGlobal(&args.callee()));
+  if (!global) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+  JS::RootedValue contextRefVal(
+      cx,
+      JS::GetReservedSlot(global, static_cast<size_t>(Context::Slots::REF)));
+  if (!contextRefVal.isInt32()) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+  unsigned contextRef = contextRefVal.toPrivateUint32();
+
+  ContextPtr ctx = goFunctionContext(contextRef);
+  if (!ctx) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+
+  const JS::Value &handleObjVal =
+      js::GetFunctionNativeReserved(&args.callee(), 0);
+  if (!handleObjVal.isObject()) {
+    JS_ReportErrorASCII(cx, "invalid function handle");
+    return false;
+  }
+  JS::Value handleVal = JS::GetReservedSlot(
+      &handleObjVal.toObject(),
+      static_cast<size_t>(FunctionHandleSlots::HANDLE));
+  if (!handleVal.isInt32()) {
+    JS_ReportErrorASCII(cx, "invalid function handle");
+    return false;
+  }
+  unsigned handle = handleVal.toPrivateUint32();
+
+  std::vector<Value *> vals = {};
+  for (unsigned i = 0; i < args.length(); i++) {
+    Value *v = new Value(ctx, args.get(i));
+    if (!v) {
+      JS_ReportOutOfMemory(cx);
+      return false;
+    }
+    vals.push_back(v);
+  }
+  JS::RootedValue thisVal(cx);
+  JS::RootedValue newTargetVal(cx);
+  if (args.isConstructing()) {
+    JS::RootedObject thisObj(
+        cx, JS_NewObjectForConstructor(cx, &HostObjectClass, args));
+    if (!thisObj) {
+      return false;
+    }
+    JS_SetReservedSlot(thisObj,
+                       static_cast<uint32_t>(HostObjectSlots::CONTEXT_REF),
+                       JS::PrivateUint32Value(contextRef));
+    thisVal.setObject(*thisObj);
+    newTargetVal.set(args.newTarget());
+  } else {
+    thisVal.set(args.thisv());
+  }
+  Value thisv(ctx, thisVal);
+  Value newTarget(ctx, newTargetVal);
+  Value callee(ctx, args.calleev());
+  JS::RootedValue rval(cx);
+
+  ResultGoFunctionCallback result =
+      goFunctionCallback(contextRef, handle, &thisv, &newTarget, &callee,
+                         vals.size(), vals.data());
+
+  if (result.ptr) {
+    rval.set(result.ptr->getJSValue());
+
+    // the callback may return one of the values owned by the call
+    if (result.ptr != &thisv && result.ptr != &newTarget &&
+        result.ptr != &callee &&
+        std::find(vals.begin(), vals.end(), result.ptr) == vals.end()) {
+      delete result.ptr;
+    }
+  }
+  for (const auto &val : vals) {
+    delete val;
+  }
+
+  if (result.thrown) {
+    JS_SetPendingException(cx, rval);
+    return false;
+  }
+  if (result.err) {
+    if (result.errName) {
+      JS_ReportErrorNumberUTF8(cx, GetErrorFormat, nullptr,
+                               GetErrorType(result.errName), result.err);
+    } else {
+      JS_ReportErrorUTF8(cx, "%s", result.err);
+    }
+    JS::RootedValue propsVal(cx);
+    if (result.errProps) {
+      propsVal.set(result.errProps->getJSValue());
+      delete result.errProps;
+    }
+    SetErrorProperties(cx, result.errName, propsVal);
+    if (result.errHandle) {
+      SetErrorHandle(cx, contextRef, result.errHandle);
+    }
+    JS_free(cx, result.errName);
+    JS_free(cx, result.err);
+    return false;
+  }
+  if (args.isConstructing() && !rval.isObject()) {
+    rval.set(thisVal);
+  }
+
+  args.rval().set(rval);
+  return true;
+}
+
+static JSFunction *NewFunctionWithHandle(JSContext *cx, const char *name,
+                                         unsigned contextRef, unsigned handle,
+                                         unsigned nargs, unsigned flags) {
+  JS::RootedFunction func(cx);
+  func = js::NewFunctionWithReserved(cx, &FunctionCallback, nargs, flags, name);
+  if (!func) {
+    return nullptr;
+  }
+  JS::RootedObject funcObj(cx, JS_GetFunctionObject(func));
+  if (!SetFunctionHandle(cx, funcObj, contextRef, handle)) {
+    return nullptr;
+  }
+  return func;
+}
+
+static bool GetContextRef(JSContext *cx, unsigned *contextRef) {
+  JS::RootedObject global(cx, JS::CurrentGlobalOrNull(cx));
+  if (!global) {
+    return false;
+  }
+  JS::RootedValue contextRefVal(
+      cx,
+      JS::GetReservedSlot(global, static_cast<size_t>(Context::Slots::REF)));
+  if (!contextRefVal.isInt32()) {
+    return false;
+  }
+  *contextRef = contextRefVal.toPrivateUint32();
+  return true;
+}
+
+static JS::UniqueChars EncodePrivateName(JSContext *cx,
+                                         JS::HandleValue privateValue) {
+  if (!privateValue.isString()) {
+    return nullptr;
+  }
+  JS::RootedString name(cx, privateValue.toString());
+  return JS_EncodeStringToUTF8(cx, name);
+}
+
+static JSObject *ModuleResolveHook(JSContext *cx,
+                                   JS::HandleValue referencingPrivate,
+                                   JS::HandleObject moduleRequest) {
+  unsigned contextRef;
+  if (!GetContextRef(cx, &contextRef)) {
+    JS_ReportOutOfMemory(cx);
+    return nullptr;
+  }
+
+  JS::RootedString specifierStr(cx);
+  specifierStr = JS::GetModuleRequestSpecifier(cx, moduleRequest);
+  if (!specifierStr) {
+    return nullptr;
+  }
+  JS::UniqueChars specifier = JS_EncodeStringToUTF8(cx, specifierStr);
+  if (!specifier) {
+    return nullptr;
+  }
+  JS::UniqueChars referrer = EncodePrivateName(cx, referencingPrivate);
+
+  ResultGoModuleResolve result =
+      goModuleResolve(contextRef, referrer.get(), specifier.get());
+  if (result.err) {
+    // the error keeps the name of the loader error, such as the SyntaxError
+    // of a module failing to compile
+    if (result.errName) {
+      JS_ReportErrorNumberUTF8(cx, GetErrorFormat, nullptr,
+                               GetErrorType(result.errName), result.err);
+    } else {
+      JS_ReportErrorUTF8(cx, "%s", result.err);
+    }
+    if (result.errHandle) {
+      SetErrorHandle(cx, contextRef, result.errHandle);
+    }
+    JS_free(cx, result.errName);
+    JS_free(cx, result.err);
+    return nullptr;
+  }
+
+  return result.ptr->getJSObject();
+}
+
+static bool ModuleDynamicImportHook(JSContext *cx,
+                                    JS::HandleValue referencingPrivate,
+                                    JS::HandleObject moduleRequest,
+                                    JS::HandleObject promise) {
+  unsigned contextRef;
+  if (!GetContextRef(cx, &contextRef)) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+  ContextPtr ctx = goFunctionContext(contextRef);
+  if (!ctx) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+
+  JS::RootedString specifierStr(cx);
+  specifierStr = JS::GetModuleRequestSpecifier(cx, moduleRequest);
+  if (!specifierStr) {
+    return false;
+  }
+  JS::UniqueChars specifier = JS_EncodeStringToUTF8(cx, specifierStr);
+  if (!specifier) {
+    return false;
+  }
+  JS::UniqueChars referrer = EncodePrivateName(cx, referencingPrivate);
+
+  DynamicImport *imp =
+      new DynamicImport(ctx, referencingPrivate, moduleRequest, promise);
+  if (!imp) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+
+  // the promise is rejected with the error if it is not settled yet
+  char *err =
+      goModuleDynamicImport(contextRef, imp, referrer.get(), specifier.get());
+  if (err) {
+    JS_ReportErrorUTF8(cx, "%s", err);
+    JS_free(cx, err);
+    return false;
+  }
+
+  return true;
+}
+
+static bool ModuleMetadataHook(JSContext *cx, JS::HandleValue privateValue,
+                               JS::HandleObject metaObject) {
+  unsigned contextRef;
+  if (!GetContextRef(cx, &contextRef)) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+  ContextPtr ctx = goFunctionContext(contextRef);
+  if (!ctx) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+
+  JS::UniqueChars name = EncodePrivateName(cx, privateValue);
+
+  JS::RootedValue metaVal(cx, JS::ObjectValue(*metaObject));
+  Value *meta = new Value(ctx, metaVal);
+  if (!meta) {
+    JS_ReportOutOfMemory(cx);
+    return false;
+  }
+
+  char *err = goModuleMetadata(contextRef, name.get(), meta);
+  delete meta;
+  if (err) {
+    JS_ReportErrorUTF8(cx, "%s", err);
+    JS_free(cx, err);
+    return false;
+  }
+
+  return true;
+}
+
+static bool StringifyCallback(const char16_t *buf, uint32_t len, void *data) {
+  std::u16string *str = static_cast<std::u16string *>(data);
+  str->append(buf, len);
+  return true;
+}
+
+/*
+ * Public functions.
+ */
+
+bool Init() {
+  JS::SetProcessBuildIdOp(BuildIdOp);
+
+  return JS_Init();
+}
+
+void ShutDown() {
+  stencilWorker.stop();
+  JS_ShutDown();
+}
+
+const char *Version() {
+  const std::string version = std::to_string(MOZJS_MAJOR_VERSION) + "." +
+                              std::to_string(MOZJS_MINOR_VERSION);
+
+  char *str = strdup(version.c_str());
+
+  return str;
+}
+
+const char *BuildId() { return strdup(GetBuildId().c_str()); }
+
+ContextPtr NewContext(unsigned ref, ContextOptions options) {
+  uint32_t heapMaxBytes = JS::DefaultHeapMaxBytes;
+  if (options.heapMaxBytes) {
+    heapMaxBytes = options.heapMaxBytes;
+  }
+
+  JSContext *cx = JS_NewContext(heapMaxBytes);
+  if (!cx) {
+    return nullptr;
+  }
+  if (options.stackSize) {
+    JS_SetNativeStackQuota(cx, options.stackSize);
+  }
+#ifdef DEBUG
+  JS_SetGCZeal(cx, 14, 1);
 #endif
   if (options.gcMaxBytes) {
     JS_SetGCParameter(cx, JSGC_MAX_BYTES, options.gcMaxBytes);
   }
-  if (options.gcIncrementalEnabled) {
-    JS_SetGCParameter(cx, JSGC_INCREMENTAL_GC_ENABLED,
-                      options.gcIncrementalEnabled);
+  if (options.gcIncrementalEnabled) {
+    JS_SetGCParameter(cx, JSGC_INCREMENTAL_GC_ENABLED,
+                      options.gcIncrementalEnabled);
+  }
+  if (options.gcSliceTimeBudgetMs) {
+    JS_SetGCParameter(cx, JSGC_SLICE_TIME_BUDGET_MS,
+                      options.gcSliceTimeBudgetMs);
+  }
+
+  if (!JS_AddInterruptCallback(cx, &InterruptCallback)) {
+    return nullptr;
+  }
+
+  JS::SetModuleResolveHook(JS_GetRuntime(cx), &ModuleResolveHook);
+  JS::SetModuleDynamicImportHook(JS_GetRuntime(cx), &ModuleDynamicImportHook);
+  JS::SetModuleMetadataHook(JS_GetRuntime(cx), &ModuleMetadataHook);
+
+  JobQueue *jobQueue = new JobQueue(cx);
+  if (!jobQueue) {
+    return nullptr;
+  }
+  JS::SetJobQueue(cx, jobQueue);
+
+  if (!JS::InitSelfHostedCode(cx)) {
+    return nullptr;
+  }
+
+  JS::RootedObject global(cx, CreateGlobalObject(cx, options.globalResolve));
+  if (!global) {
+    return nullptr;
+  }
+  JSAutoRealm ar(cx, global);
+
+  JS::RootedValue contextRefVal(cx, JS::PrivateUint32Value(ref));
+  JS_SetReservedSlot(global, static_cast<uint32_t>(Context::Slots::REF),
+                     contextRefVal);
+
+  Context *ctx = new Context(ref, cx, global, jobQueue);
+  if (!ctx) {
+    return nullptr;
+  }
+  JS_SetContextPrivate(cx, ctx);
+  return ctx;
+}
+
+void DestroyContext(ContextPtr ctx) {
+  JSContext *cx = ctx->getJSContext();
+  JS_SetContextPrivate(cx, nullptr);
+  delete ctx;
+  JS_DestroyContext(cx);
+}
+
+ResultException TakeException(ContextPtr ctx) {
+  ResultException result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue exception(ctx->getJSContext(), ctx->getException());
+  JS::RootedObject stack(ctx->getJSContext(), ctx->getExceptionStack());
+  ctx->clearException();
+  if (exception.isObject()) {
+    JS::RootedObject exceptionObj(ctx->getJSContext(), &exception.toObject());
+    JSObject *errorStack = JS::ExceptionStackOrNull(exceptionObj);
+    if (errorStack) {
+      stack = errorStack;
+    }
+  }
+
+  Value *v = new Value(ctx, exception);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  result.stack = GetStackString(ctx->getJSContext(), stack);
+  return result;
+}
+
+ResultException GetErrorCause(ValuePtr value) {
+  ResultException result = {};
+
+  Context *ctx = value->getContext();
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue val(ctx->getJSContext(), value->getJSValue());
+  if (!val.isObject()) {
+    return result;
+  }
+  mozilla::Maybe<JS::Value> cause = JS::GetExceptionCause(&val.toObject());
+  if (cause.isNothing()) {
+    return result;
+  }
+  JS::RootedValue causeVal(ctx->getJSContext(), *cause);
+  JS::RootedObject stack(ctx->getJSContext());
+  if (causeVal.isObject()) {
+    JS::RootedObject causeObj(ctx->getJSContext(), &causeVal.toObject());
+    stack = JS::ExceptionStackOrNull(causeObj);
+  }
+
+  JS::ExceptionStack exceptionStack(ctx->getJSContext(), causeVal, stack);
+  JS::ErrorReportBuilder builder(ctx->getJSContext());
+  if (!builder.init(ctx->getJSContext(), exceptionStack,
+                    JS::ErrorReportBuilder::WithSideEffects)) {
+    JS_ClearPendingException(ctx->getJSContext());
+    return result;
+  }
+  if (!SetErrorReport(&result.err, builder.report())) {
+    return result;
+  }
+  GetErrorHandle(ctx->getJSContext(), causeVal, &result.err);
+
+  Value *v = new Value(ctx, causeVal);
+  if (!v) {
+    free((void *)result.err.message);
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  result.stack = GetStackString(ctx->getJSContext(), stack);
+  return result;
+}
+
+void RequestInterruptContext(ContextPtr ctx) {
+  JS_RequestInterruptCallback(ctx->getJSContext());
+}
+
+void GCContext(ContextPtr ctx) { JS_GC(ctx->getJSContext()); }
+
+Result RunJobs(ContextPtr ctx) {
+  Result result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  if (!ctx->getJobQueue()->drain(ctx->getJSContext())) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  result.ok = true;
+  return result;
+}
+
+ResultValue GetGlobalObject(ContextPtr ctx) {
+  ResultValue result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  JS::RootedValue globalVal(ctx->getJSContext());
+  globalVal.setObject(*global);
+
+  Value *v = new Value(ctx, globalVal);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
+ResultValue DefineObject(ContextPtr ctx, ValuePtr recv, char *name,
+                         unsigned attrs) {
+  ResultValue result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
+  JS::RootedObject recvObject(ctx->getJSContext(),
+                              JS::ToObject(ctx->getJSContext(), recvValue));
+  if (!recvObject) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  JS::RootedObject object(
+      ctx->getJSContext(),
+      JS_DefineObject(ctx->getJSContext(), recvObject, name, nullptr, attrs));
+  if (!object) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  JS::RootedValue objectVal(ctx->getJSContext());
+  objectVal.setObject(*object);
+
+  Value *v = new Value(ctx, objectVal);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
+Result DefineProperty(ContextPtr ctx, ValuePtr recv, char *name, ValuePtr value,
+                      unsigned attrs) {
+  Result result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
+  JS::RootedObject recvObject(ctx->getJSContext(),
+                              JS::ToObject(ctx->getJSContext(), recvValue));
+  if (!recvObject) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  JS::RootedValue propValue(ctx->getJSContext(), value->getJSValue());
+
+  if (!JS_DefineProperty(ctx->getJSContext(), recvObject, name, propValue,
+                         attrs)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  result.ok = true;
+  return result;
+}
+
+Result DefineElement(ContextPtr ctx, ValuePtr recv, uint32_t index,
+                     ValuePtr value, unsigned attrs) {
+  Result result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
+  JS::RootedObject recvObject(ctx->getJSContext(),
+                              JS::ToObject(ctx->getJSContext(), recvValue));
+  if (!recvObject) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  JS::RootedValue propValue(ctx->getJSContext(), value->getJSValue());
+
+  if (!JS_DefineElement(ctx->getJSContext(), recvObject, index, propValue,
+                        attrs)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  result.ok = true;
+
+  return result;
+}
+
+Result DefineFunction(ContextPtr ctx, ValuePtr recv, char *name,
+                      unsigned handle, unsigned nargs, unsigned attrs) {
+  Result result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
+  JS::RootedObject recvObject(ctx->getJSContext(),
+                              JS::ToObject(ctx->getJSContext(), recvValue));
+  if (!recvObject) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedFunction func(
+      ctx->getJSContext(),
+      js::DefineFunctionWithReserved(ctx->getJSContext(), recvObject, name,
+                                     &FunctionCallback, nargs, attrs));
+  if (!func) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedObject funcObj(ctx->getJSContext(), JS_GetFunctionObject(func));
+  if (!funcObj) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  if (!SetFunctionHandle(ctx->getJSContext(), funcObj, ctx->getRef(),
+                         handle)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  result.ok = true;
+  return result;
+}
+
+ResultValue DefineClass(ContextPtr ctx, ValuePtr recv, char *name,
+                        unsigned handle, unsigned nargs, unsigned attrs) {
+  ResultValue result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
+  JS::RootedObject recvObject(ctx->getJSContext(),
+                              JS::ToObject(ctx->getJSContext(), recvValue));
+  if (!recvObject) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
   }
-  if (options.gcSliceTimeBudgetMs) {
-    JS_SetGCParameter(cx, JSGC_SLICE_TIME_BUDGET_MS,
-                      options.gcSliceTimeBudgetMs);
+  JS::RootedFunction func(
+      ctx->getJSContext(),
+      NewFunctionWithHandle(ctx->getJSContext(), name, ctx->getRef(), handle,
+                            nargs, JSFUN_CONSTRUCTOR));
+  if (!func) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedObject funcObj(ctx->getJSContext(), JS_GetFunctionObject(func));
+  JS::RootedObject proto(ctx->getJSContext(),
+                         JS_NewPlainObject(ctx->getJSContext()));
+  if (!proto) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  if (!JS_DefineProperty(ctx->getJSContext(), funcObj, "prototype", proto,
+                         JSPROP_READONLY | JSPROP_PERMANENT) ||
+      !JS_DefineProperty(ctx->getJSContext(), proto, "constructor", funcObj,
+                         0) ||
+      !JS_DefineProperty(ctx->getJSContext(), recvObject, name, funcObj,
+                         attrs)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  JS::RootedValue funcValue(ctx->getJSContext(), JS::ObjectValue(*funcObj));
+  Value *value = new Value(ctx, funcValue);
+  if (!value) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = value;
+  return result;
+}
+
+Result DefineAccessor(ContextPtr ctx, ValuePtr recv, char *name,
+                      unsigned getter, unsigned setter, unsigned attrs) {
+  Result result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
+  JS::RootedObject recvObject(ctx->getJSContext(),
+                              JS::ToObject(ctx->getJSContext(), recvValue));
+  if (!recvObject) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedObject getterObj(ctx->getJSContext());
+  if (getter) {
+    JSFunction *func = NewFunctionWithHandle(ctx->getJSContext(), name,
+                                             ctx->getRef(), getter, 0, 0);
+    if (!func) {
+      result.err = GetError(ctx->getJSContext());
+      return result;
+    }
+    getterObj = JS_GetFunctionObject(func);
+  }
+  JS::RootedObject setterObj(ctx->getJSContext());
+  if (setter) {
+    JSFunction *func = NewFunctionWithHandle(ctx->getJSContext(), name,
+                                             ctx->getRef(), setter, 1, 0);
+    if (!func) {
+      result.err = GetError(ctx->getJSContext());
+      return result;
+    }
+    setterObj = JS_GetFunctionObject(func);
+  }
+  if (!JS_DefineProperty(ctx->getJSContext(), recvObject, name, getterObj,
+                         setterObj, attrs)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  result.ok = true;
+  return result;
+}
+
+bool SetHostObjectData(ValuePtr value, unsigned handle) {
+  if (!value->getJSValue().isObject()) {
+    return false;
+  }
+  JSObject *obj = &value->getJSValue().toObject();
+  if (JS::GetClass(obj) != &HostObjectClass) {
+    return false;
+  }
+
+  JS_SetReservedSlot(obj, static_cast<uint32_t>(HostObjectSlots::HANDLE),
+                     JS::PrivateUint32Value(handle));
+  return true;
+}
+
+ResultUInt32 GetHostObjectData(ValuePtr value) {
+  ResultUInt32 result = {};
+
+  if (!value->getJSValue().isObject()) {
+    return result;
+  }
+  JSObject *obj = &value->getJSValue().toObject();
+  if (JS::GetClass(obj) != &HostObjectClass) {
+    return result;
+  }
+  JS::Value handleVal =
+      JS::GetReservedSlot(obj, static_cast<size_t>(HostObjectSlots::HANDLE));
+  if (!handleVal.isInt32()) {
+    return result;
+  }
+
+  result.ok = true;
+  result.value = handleVal.toPrivateUint32();
+  return result;
+}
+
+ResultValue GetHostObjectCache(ValuePtr value) {
+  ResultValue result = {};
+
+  JSContext *cx = value->getContext()->getJSContext();
+  JS::RootedObject global(cx, value->getContext()->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(cx);
+    return result;
+  }
+  JSAutoRealm ar(cx, global);
+
+  if (!value->getJSValue().isObject()) {
+    return result;
+  }
+  JS::RootedObject obj(cx, &value->getJSValue().toObject());
+  if (JS::GetClass(obj) != &HostObjectClass) {
+    return result;
+  }
+
+  JS::RootedValue cacheVal(
+      cx, JS::GetReservedSlot(obj, static_cast<size_t>(HostObjectSlots::CACHE)));
+  if (!cacheVal.isObject()) {
+    JSObject *cache = JS_NewObjectWithGivenProto(cx, nullptr, nullptr);
+    if (!cache) {
+      result.err = GetError(cx);
+      return result;
+    }
+    cacheVal.setObject(*cache);
+    JS_SetReservedSlot(obj, static_cast<uint32_t>(HostObjectSlots::CACHE),
+                       cacheVal);
+  }
+
+  Value *v = new Value(value->getContext(), cacheVal);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
+ResultValue CallFunctionName(ContextPtr ctx, char *name, ValuePtr recv,
+                             int argc, ValuePtr *argv) {
+  ResultValue result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
+  JS::RootedObject recvObject(ctx->getJSContext(),
+                              JS::ToObject(ctx->getJSContext(), recvValue));
+  if (!recvObject) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedValueVector values(ctx->getJSContext());
+  if (!values.resize(argc)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  for (int i = 0; i < argc; i++) {
+    values[i].set(argv[i]->getJSValue());
+  }
+  JS::HandleValueArray args(values);
+  JS::RootedValue rval(ctx->getJSContext());
+  if (!JS_CallFunctionName(ctx->getJSContext(), recvObject, name, args,
+                           &rval)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  Value *v = new Value(ctx, rval);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
+ResultValue CallFunctionValue(ContextPtr ctx, ValuePtr func, ValuePtr recv,
+                              int argc, ValuePtr *argv) {
+  ResultValue result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
+  JS::RootedObject recvObject(ctx->getJSContext(),
+                              JS::ToObject(ctx->getJSContext(), recvValue));
+  if (!recvObject) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedValue funcVal(ctx->getJSContext(), func->getJSValue());
+  JS::RootedValueVector values(ctx->getJSContext());
+  if (!values.resize(argc)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  for (int i = 0; i < argc; i++) {
+    values[i].set(argv[i]->getJSValue());
+  }
+  JS::HandleValueArray args(values);
+  JS::RootedValue rval(ctx->getJSContext());
+  if (!JS_CallFunctionValue(ctx->getJSContext(), recvObject, funcVal, args,
+                            &rval)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  Value *v = new Value(ctx, rval);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
+ResultValue Evaluate(ContextPtr ctx, char *code) {
+  ResultValue result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::CompileOptions options(ctx->getJSContext());
+  options.setFileAndLine("", 1);
+
+  JS::SourceText<mozilla::Utf8Unit> source;
+  if (!source.init(ctx->getJSContext(), code, strlen(code),
+                   JS::SourceOwnership::Borrowed)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  JS::RootedScript script(ctx->getJSContext(),
+                          JS::Compile(ctx->getJSContext(), options, source));
+  if (!script) {
+    result.err = GetError(ctx->getJSContext(), true);
+    return result;
+  }
+
+  JS::RootedValue rval(ctx->getJSContext());
+  if (!JS_ExecuteScript(ctx->getJSContext(), script, &rval)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+
+  Value *v = new Value(ctx, rval);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
+ResultCompileScript CompileScript(ContextPtr ctx, char *filename, char *code) {
+  ResultCompileScript result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
   }
+  JSAutoRealm ar(ctx->getJSContext(), global);
 
-  if (!JS_AddInterruptCallback(cx, &InterruptCallback)) {
-    return nullptr;
-  }
+  JS::CompileOptions options(ctx->getJSContext());
+  options.setFileAndLine(filename, 1);
 
-  if (!JS::InitSelfHostedCode(cx)) {
-    return nullptr;
+  JS::SourceText<mozilla::Utf8Unit> source;
+  if (!source.init(ctx->getJSContext(), code, strlen(code),
+                   JS::SourceOwnership::Borrowed)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
   }
 
-  JS::RootedObject global(cx, CreateGlobalObject(cx));
-  if (!global) {
-    return nullptr;
+  JS::RootedScript rscript(ctx->getJSContext(),
+                           JS::Compile(ctx->getJSContext(), options, source));
+  if (!rscript) {
+    result.err = GetError(ctx->getJSContext(), true);
+    return result;
   }
-  JSAutoRealm ar(cx, global);
 
-  JS::RootedValue contextRefVal(cx, JS::Int32Value(ref));
-  JS_SetReservedSlot(global, static_cast<uint32_t>(Context::Slots::REF),
-                     contextRefVal);
+  JS::RootedString name(ctx->getJSContext(),
+                        JS_NewStringCopyZ(ctx->getJSContext(), filename));
+  if (!name) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::SetScriptPrivate(rscript, JS::StringValue(name));
 
-  Context *ctx = new Context(ref, cx, global);
-  if (!ctx) {
-    return nullptr;
+  Script *script = new Script(ctx, rscript);
+  if (!script) {
+    return result;
   }
-  return ctx;
-}
 
-void DestroyContext(ContextPtr ctx) {
-  JS_DestroyContext(ctx->getJSContext());
-  delete ctx;
+  result.ok = true;
+  result.ptr = script;
+  return result;
 }
 
-void RequestInterruptContext(ContextPtr ctx) {
-  JS_RequestInterruptCallback(ctx->getJSContext());
-}
+void ReleaseScript(ScriptPtr script) { delete script; }
 
-ResultValue GetGlobalObject(ContextPtr ctx) {
+ResultValue ExecuteScript(ContextPtr ctx, ScriptPtr script) {
   ResultValue result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
@@ -387,11 +2068,17 @@ ResultValue GetGlobalObject(ContextPtr ctx) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
+  JSAutoRealm ar(ctx->getJSContext(), ctx->getGlobalJSObject());
 
-  JS::RootedValue globalVal(ctx->getJSContext());
-  globalVal.setObject(*global);
+  JS::RootedValue rval(script->getContext()->getJSContext());
+  JS::RootedScript rscript(script->getContext()->getJSContext(),
+                           script->getJSScript());
+  if (!JS_ExecuteScript(ctx->getJSContext(), rscript, &rval)) {
+    result.err = GetError(script->getContext()->getJSContext());
+    return result;
+  }
 
-  Value *v = new Value(ctx, globalVal);
+  Value *v = new Value(script->getContext(), rval);
   if (!v) {
     return result;
   }
@@ -401,38 +2088,46 @@ ResultValue GetGlobalObject(ContextPtr ctx) {
   return result;
 }
 
-ResultValue DefineObject(ContextPtr ctx, ValuePtr recv, char *name,
-                         unsigned attrs) {
+ResultValue ExecuteScriptFromStencil(ContextPtr ctx, StencilPtr stencil) {
   ResultValue result = {};
 
-  JS::PersistentRootedObject global(ctx->getJSContext(),
-                                    ctx->getGlobalJSObject());
-  if (!global) {
+  JSAutoRealm ar(ctx->getJSContext(), ctx->getGlobalJSObject());
+
+  if (stencil->isModule()) {
+    JS_ReportErrorASCII(ctx->getJSContext(), "stencil is a module");
     result.err = GetError(ctx->getJSContext());
     return result;
   }
-  JSAutoRealm ar(ctx->getJSContext(), global);
 
-  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
-  JS::RootedObject recvObject(ctx->getJSContext(),
-                              JS::ToObject(ctx->getJSContext(), recvValue));
-  if (!recvObject) {
+  JS::CompileOptions options(ctx->getJSContext());
+  JS::InstantiateOptions instantiateOptions(options);
+  JS::RootedScript script(
+      ctx->getJSContext(),
+      JS::InstantiateGlobalStencil(ctx->getJSContext(), instantiateOptions,
+                                   stencil->getStencil()));
+  if (!script) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
 
-  JS::RootedObject object(
-      ctx->getJSContext(),
-      JS_DefineObject(ctx->getJSContext(), recvObject, name, nullptr, attrs));
-  if (!object) {
+  const char *filename = JS_GetScriptFilename(script);
+  if (filename) {
+    JS::RootedString name(ctx->getJSContext(),
+                          JS_NewStringCopyZ(ctx->getJSContext(), filename));
+    if (!name) {
+      result.err = GetError(ctx->getJSContext());
+      return result;
+    }
+    JS::SetScriptPrivate(script, JS::StringValue(name));
+  }
+
+  JS::RootedValue rval(ctx->getJSContext());
+  if (!JS_ExecuteScript(ctx->getJSContext(), script, &rval)) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
 
-  JS::RootedValue objectVal(ctx->getJSContext());
-  objectVal.setObject(*object);
-
-  Value *v = new Value(ctx, objectVal);
+  Value *v = new Value(ctx, rval);
   if (!v) {
     return result;
   }
@@ -442,9 +2137,8 @@ ResultValue DefineObject(ContextPtr ctx, ValuePtr recv, char *name,
   return result;
 }
 
-Result DefineProperty(ContextPtr ctx, ValuePtr recv, char *name, ValuePtr value,
-                      unsigned attrs) {
-  Result result = {};
+ResultCompileModule CompileModule(ContextPtr ctx, char *filename, char *code) {
+  ResultCompileModule result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
                                     ctx->getGlobalJSObject());
@@ -454,28 +2148,45 @@ Result DefineProperty(ContextPtr ctx, ValuePtr recv, char *name, ValuePtr value,
   }
   JSAutoRealm ar(ctx->getJSContext(), global);
 
-  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
-  JS::RootedObject recvObject(ctx->getJSContext(),
-                              JS::ToObject(ctx->getJSContext(), recvValue));
-  if (!recvObject) {
+  JS::CompileOptions options(ctx->getJSContext());
+  options.setFileAndLine(filename, 1);
+
+  JS::SourceText<mozilla::Utf8Unit> source;
+  if (!source.init(ctx->getJSContext(), code, strlen(code),
+                   JS::SourceOwnership::Borrowed)) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
 
-  JS::RootedValue propValue(ctx->getJSContext(), value->getJSValue());
+  JS::RootedObject rmodule(
+      ctx->getJSContext(),
+      JS::CompileModule(ctx->getJSContext(), options, source));
+  if (!rmodule) {
+    result.err = GetError(ctx->getJSContext(), true);
+    return result;
+  }
 
-  if (!JS_DefineProperty(ctx->getJSContext(), recvObject, name, propValue,
-                         attrs)) {
+  JS::RootedString name(ctx->getJSContext(),
+                        JS_NewStringCopyZ(ctx->getJSContext(), filename));
+  if (!name) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
+  JS::SetModulePrivate(rmodule, JS::StringValue(name));
+
+  Module *module = new Module(ctx, rmodule);
+  if (!module) {
+    return result;
+  }
 
   result.ok = true;
+  result.ptr = module;
   return result;
 }
 
-Result DefineElement(ContextPtr ctx, ValuePtr recv, uint32_t index,
-                     ValuePtr value, unsigned attrs) {
+void ReleaseModule(ModulePtr module) { delete module; }
+
+Result LinkModule(ContextPtr ctx, ModulePtr module) {
   Result result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
@@ -486,30 +2197,18 @@ Result DefineElement(ContextPtr ctx, ValuePtr recv, uint32_t index,
   }
   JSAutoRealm ar(ctx->getJSContext(), global);
 
-  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
-  JS::RootedObject recvObject(ctx->getJSContext(),
-                              JS::ToObject(ctx->getJSContext(), recvValue));
-  if (!recvObject) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
-  }
-
-  JS::RootedValue propValue(ctx->getJSContext(), value->getJSValue());
-
-  if (!JS_DefineElement(ctx->getJSContext(), recvObject, index, propValue,
-                        attrs)) {
+  JS::RootedObject rmodule(ctx->getJSContext(), module->getJSObject());
+  if (!JS::ModuleLink(ctx->getJSContext(), rmodule)) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
 
   result.ok = true;
-
   return result;
 }
 
-Result DefineFunction(ContextPtr ctx, ValuePtr recv, char *name, unsigned nargs,
-                      unsigned attrs) {
-  Result result = {};
+ResultValue EvaluateModule(ContextPtr ctx, ModulePtr module) {
+  ResultValue result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
                                     ctx->getGlobalJSObject());
@@ -519,33 +2218,39 @@ Result DefineFunction(ContextPtr ctx, ValuePtr recv, char *name, unsigned nargs,
   }
   JSAutoRealm ar(ctx->getJSContext(), global);
 
-  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
-  JS::RootedObject recvObject(ctx->getJSContext(),
-                              JS::ToObject(ctx->getJSContext(), recvValue));
-  if (!recvObject) {
+  JS::RootedObject rmodule(ctx->getJSContext(), module->getJSObject());
+  JS::RootedValue rval(ctx->getJSContext());
+  if (!JS::ModuleEvaluate(ctx->getJSContext(), rmodule, &rval)) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
-  JS::RootedFunction func(
-      ctx->getJSContext(),
-      JS_DefineFunction(ctx->getJSContext(), recvObject, name,
-                        &FunctionCallback, nargs, attrs));
-  if (!func) {
+  if (!rval.isObject()) {
+    JS_ReportErrorASCII(ctx->getJSContext(), "invalid evaluation promise");
     result.err = GetError(ctx->getJSContext());
     return result;
   }
-  JS::RootedObject funcObj(ctx->getJSContext(), JS_GetFunctionObject(func));
-  if (!funcObj) {
+
+  JS::RootedObject promise(ctx->getJSContext(), &rval.toObject());
+  if (JS::GetPromiseState(promise) == JS::PromiseState::Rejected) {
+    JS::RootedValue reason(ctx->getJSContext(),
+                           JS::GetPromiseResult(promise));
+    JS::SetSettledPromiseIsHandled(ctx->getJSContext(), promise);
+    JS_SetPendingException(ctx->getJSContext(), reason);
     result.err = GetError(ctx->getJSContext());
     return result;
   }
 
+  Value *v = new Value(ctx, rval);
+  if (!v) {
+    return result;
+  }
+
   result.ok = true;
+  result.ptr = v;
   return result;
 }
 
-ResultValue CallFunctionName(ContextPtr ctx, char *name, ValuePtr recv,
-                             int argc, ValuePtr *argv) {
+ResultValue GetModuleNamespaceObject(ContextPtr ctx, ModulePtr module) {
   ResultValue result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
@@ -556,30 +2261,17 @@ ResultValue CallFunctionName(ContextPtr ctx, char *name, ValuePtr recv,
   }
   JSAutoRealm ar(ctx->getJSContext(), global);
 
-  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
-  JS::RootedObject recvObject(ctx->getJSContext(),
-                              JS::ToObject(ctx->getJSContext(), recvValue));
-  if (!recvObject) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
-  }
-  JS::RootedValueVector values(ctx->getJSContext());
-  if (!values.resize(argc)) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
-  }
-  for (int i = 0; i < argc; i++) {
-    values[i].set(argv[i]->getJSValue());
-  }
-  JS::HandleValueArray args(values);
-  JS::RootedValue rval(ctx->getJSContext());
-  if (!JS_CallFunctionName(ctx->getJSContext(), recvObject, name, args,
-                           &rval)) {
+  JS::RootedObject rmodule(ctx->getJSContext(), module->getJSObject());
+  JS::RootedObject ns(ctx->getJSContext(),
+                      JS::GetModuleNamespace(ctx->getJSContext(), rmodule));
+  if (!ns) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
+  JS::RootedValue nsVal(ctx->getJSContext());
+  nsVal.setObject(*ns);
 
-  Value *v = new Value(ctx, rval);
+  Value *v = new Value(ctx, nsVal);
   if (!v) {
     return result;
   }
@@ -589,9 +2281,9 @@ ResultValue CallFunctionName(ContextPtr ctx, char *name, ValuePtr recv,
   return result;
 }
 
-ResultValue CallFunctionValue(ContextPtr ctx, ValuePtr func, ValuePtr recv,
-                              int argc, ValuePtr *argv) {
-  ResultValue result = {};
+ResultCompileModule InstantiateModuleFromStencil(ContextPtr ctx,
+                                                 StencilPtr stencil) {
+  ResultCompileModule result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
                                     ctx->getGlobalJSObject());
@@ -601,42 +2293,48 @@ ResultValue CallFunctionValue(ContextPtr ctx, ValuePtr func, ValuePtr recv,
   }
   JSAutoRealm ar(ctx->getJSContext(), global);
 
-  JS::RootedValue recvValue(ctx->getJSContext(), recv->getJSValue());
-  JS::RootedObject recvObject(ctx->getJSContext(),
-                              JS::ToObject(ctx->getJSContext(), recvValue));
-  if (!recvObject) {
+  if (!stencil->isModule()) {
+    JS_ReportErrorASCII(ctx->getJSContext(), "stencil is not a module");
     result.err = GetError(ctx->getJSContext());
     return result;
   }
-  JS::RootedValue funcVal(ctx->getJSContext(), func->getJSValue());
-  JS::RootedValueVector values(ctx->getJSContext());
-  if (!values.resize(argc)) {
+
+  JS::CompileOptions options(ctx->getJSContext());
+  JS::InstantiateOptions instantiateOptions(options);
+  JS::RootedObject rmodule(
+      ctx->getJSContext(),
+      JS::InstantiateModuleStencil(ctx->getJSContext(), instantiateOptions,
+                                   stencil->getStencil()));
+  if (!rmodule) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
-  for (int i = 0; i < argc; i++) {
-    values[i].set(argv[i]->getJSValue());
-  }
-  JS::HandleValueArray args(values);
-  JS::RootedValue rval(ctx->getJSContext());
-  if (!JS_CallFunctionValue(ctx->getJSContext(), recvObject, funcVal, args,
-                            &rval)) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
+
+  JS::RootedScript script(ctx->getJSContext(), JS::GetModuleScript(rmodule));
+  const char *filename = script ? JS_GetScriptFilename(script) : nullptr;
+  if (filename) {
+    JS::RootedString name(ctx->getJSContext(),
+                          JS_NewStringCopyZ(ctx->getJSContext(), filename));
+    if (!name) {
+      result.err = GetError(ctx->getJSContext());
+      return result;
+    }
+    JS::SetModulePrivate(rmodule, JS::StringValue(name));
   }
 
-  Value *v = new Value(ctx, rval);
-  if (!v) {
+  Module *module = new Module(ctx, rmodule);
+  if (!module) {
     return result;
   }
 
   result.ok = true;
-  result.ptr = v;
+  result.ptr = module;
   return result;
 }
 
-ResultValue Evaluate(ContextPtr ctx, char *code) {
-  ResultValue result = {};
+Result FinishDynamicImport(ContextPtr ctx, DynamicImportPtr imp,
+                           ModulePtr module) {
+  Result result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
                                     ctx->getGlobalJSObject());
@@ -646,34 +2344,34 @@ ResultValue Evaluate(ContextPtr ctx, char *code) {
   }
   JSAutoRealm ar(ctx->getJSContext(), global);
 
-  JS::CompileOptions options(ctx->getJSContext());
-  options.setFileAndLine("", 1);
-
-  JS::SourceText<mozilla::Utf8Unit> source;
-  if (!source.init(ctx->getJSContext(), code, strlen(code),
-                   JS::SourceOwnership::Borrowed)) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
-  }
+  JS::RootedValue referencingPrivate(ctx->getJSContext(),
+                                     imp->getReferencingPrivate());
+  JS::RootedObject moduleRequest(ctx->getJSContext(),
+                                 imp->getModuleRequest());
+  JS::RootedObject promise(ctx->getJSContext(), imp->getPromise());
+  JS::RootedObject evaluationPromise(ctx->getJSContext());
 
+  JS::RootedObject rmodule(ctx->getJSContext(), module->getJSObject());
   JS::RootedValue rval(ctx->getJSContext());
-  if (!JS::Evaluate(ctx->getJSContext(), options, source, &rval)) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
+  if (JS::ModuleLink(ctx->getJSContext(), rmodule) &&
+      JS::ModuleEvaluate(ctx->getJSContext(), rmodule, &rval) &&
+      rval.isObject()) {
+    evaluationPromise = &rval.toObject();
   }
 
-  Value *v = new Value(ctx, rval);
-  if (!v) {
+  if (!JS::FinishDynamicModuleImport(ctx->getJSContext(), evaluationPromise,
+                                     referencingPrivate, moduleRequest,
+                                     promise)) {
+    result.err = GetError(ctx->getJSContext());
     return result;
   }
 
   result.ok = true;
-  result.ptr = v;
   return result;
 }
 
-ResultCompileScript CompileScript(ContextPtr ctx, char *filename, char *code) {
-  ResultCompileScript result = {};
+Result FailDynamicImport(ContextPtr ctx, DynamicImportPtr imp, char *message) {
+  Result result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
                                     ctx->getGlobalJSObject());
@@ -683,138 +2381,175 @@ ResultCompileScript CompileScript(ContextPtr ctx, char *filename, char *code) {
   }
   JSAutoRealm ar(ctx->getJSContext(), global);
 
-  JS::CompileOptions options(ctx->getJSContext());
-  options.setFileAndLine(filename, 1);
+  JS::RootedValue referencingPrivate(ctx->getJSContext(),
+                                     imp->getReferencingPrivate());
+  JS::RootedObject moduleRequest(ctx->getJSContext(),
+                                 imp->getModuleRequest());
+  JS::RootedObject promise(ctx->getJSContext(), imp->getPromise());
 
-  JS::SourceText<mozilla::Utf8Unit> source;
-  if (!source.init(ctx->getJSContext(), code, strlen(code),
-                   JS::SourceOwnership::Borrowed)) {
+  JS_ReportErrorUTF8(ctx->getJSContext(), "%s", message);
+  if (!JS::FinishDynamicModuleImport(ctx->getJSContext(), nullptr,
+                                     referencingPrivate, moduleRequest,
+                                     promise)) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
 
-  JS::RootedScript rscript(ctx->getJSContext(),
-                           JS::Compile(ctx->getJSContext(), options, source));
-  if (!rscript) {
-    result.err = GetError(ctx->getJSContext());
+  result.ok = true;
+  return result;
+}
+
+void ReleaseDynamicImport(DynamicImportPtr imp) { delete imp; }
+
+FrontendContextPtr NewFrontendContext(FrontendContextOptions options) {
+  FrontendContext *ctx = new FrontendContext(options.stackSize);
+  if (!ctx) {
+    return nullptr;
+  }
+  return ctx;
+}
+
+void DestroyFrontendContext(FrontendContextPtr ctx) { delete ctx; }
+
+// CompileStencil compiles a script or a module to a stencil in the context of
+// the stencil worker, which reports the compilation errors.
+static RefPtr<JS::Stencil> CompileStencil(FrontendContextPtr ctx,
+                                          char *filename, char *code,
+                                          bool module, Error *err,
+                                          size_t *tokenLength = nullptr) {
+  RefPtr<JS::Stencil> st;
+  bool ok = stencilWorker.run([&](JSContext *cx) {
+    size_t stackSize = ctx->getStackSize();
+    JS_SetNativeStackQuota(cx, stackSize && stackSize < StencilWorkerStackQuota
+                                   ? stackSize
+                                   : StencilWorkerStackQuota);
+
+    JS::CompileOptions options(cx);
+    options.setFileAndLine(filename, 1);
+
+    JS::SourceText<mozilla::Utf8Unit> source;
+    if (source.init(cx, code, strlen(code), JS::SourceOwnership::Borrowed)) {
+      st = module ? JS::CompileModuleScriptToStencil(cx, options, source)
+                  : JS::CompileGlobalScriptToStencil(cx, options, source);
+    }
+    if (!st) {
+      *err = GetError(cx, true, tokenLength);
+      err->filename = filename;
+      return false;
+    }
+    return true;
+  });
+  if (!ok) {
+    if (!err->message) {
+      err->message = strdup(module ? "compile module" : "compile script");
+      err->filename = filename;
+    }
+    return nullptr;
+  }
+  return st;
+}
+
+ResultCompileStencil CompileScriptToStencil(FrontendContextPtr ctx,
+                                            char *filename, char *code) {
+  ResultCompileStencil result = {};
+
+  RefPtr<JS::Stencil> st =
+      CompileStencil(ctx, filename, code, false, &result.err);
+  if (!st) {
     return result;
   }
 
-  Script *script = new Script(ctx, rscript);
-  if (!script) {
+  Stencil *stencil = new Stencil(st);
+  if (!stencil) {
     return result;
   }
 
   result.ok = true;
-  result.ptr = script;
+  result.ptr = stencil;
   return result;
 }
 
-void ReleaseScript(ScriptPtr script) { delete script; }
-
-ResultValue ExecuteScript(ContextPtr ctx, ScriptPtr script) {
-  ResultValue result = {};
-
-  JS::PersistentRootedObject global(ctx->getJSContext(),
-                                    ctx->getGlobalJSObject());
-  if (!global) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
-  }
-  JSAutoRealm ar(ctx->getJSContext(), ctx->getGlobalJSObject());
+ResultCompileStencil CompileModuleToStencil(FrontendContextPtr ctx,
+                                            char *filename, char *code) {
+  ResultCompileStencil result = {};
 
-  JS::RootedValue rval(script->getContext()->getJSContext());
-  JS::RootedScript rscript(script->getContext()->getJSContext(),
-                           script->getJSScript());
-  if (!JS_ExecuteScript(ctx->getJSContext(), rscript, &rval)) {
-    result.err = GetError(script->getContext()->getJSContext());
+  RefPtr<JS::Stencil> st =
+      CompileStencil(ctx, filename, code, true, &result.err);
+  if (!st) {
     return result;
   }
 
-  Value *v = new Value(script->getContext(), rval);
-  if (!v) {
+  Stencil *stencil = new Stencil(st, true);
+  if (!stencil) {
     return result;
   }
 
   result.ok = true;
-  result.ptr = v;
+  result.ptr = stencil;
   return result;
 }
 
-ResultValue ExecuteScriptFromStencil(ContextPtr ctx, StencilPtr stencil) {
-  ResultValue result = {};
-
-  JSAutoRealm ar(ctx->getJSContext(), ctx->getGlobalJSObject());
-
-  JS::CompileOptions options(ctx->getJSContext());
-  JS::InstantiateOptions instantiateOptions(options);
-  JS::RootedScript script(
-      ctx->getJSContext(),
-      JS::InstantiateGlobalStencil(ctx->getJSContext(), instantiateOptions,
-                                   stencil->getStencil()));
-  if (!script) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
-  }
-
-  JS::RootedValue rval(ctx->getJSContext());
-  if (!JS_ExecuteScript(ctx->getJSContext(), script, &rval)) {
-    result.err = GetError(ctx->getJSContext());
-    return result;
-  }
+// The public API has no parse-only entry point, so the syntax is checked by a
+// compilation parsing the functions lazily, whose stencil is released at once.
+ResultCheckSyntax CheckSyntax(FrontendContextPtr ctx, char *filename,
+                              char *code, bool module) {
+  ResultCheckSyntax result = {};
 
-  Value *v = new Value(ctx, rval);
-  if (!v) {
+  size_t tokenLength = 0;
+  RefPtr<JS::Stencil> st =
+      CompileStencil(ctx, filename, code, module, &result.err, &tokenLength);
+  if (!st) {
+    result.tokenLength = tokenLength;
     return result;
   }
 
   result.ok = true;
-  result.ptr = v;
   return result;
 }
 
-FrontendContextPtr NewFrontendContext(FrontendContextOptions options) {
-  JS::FrontendContext *fc = JS::NewFrontendContext();
-  if (!fc) {
-    return nullptr;
-  }
-  if (options.stackSize) {
-    JS::SetNativeStackQuota(fc, 0);
+ResultString EncodeStencil(StencilPtr stencil) {
+  ResultString result = {};
+
+  // The encoder requires a JS context, which is bound to the thread creating
+  // it, so the stencils are encoded by a shared context on a dedicated thread.
+  JS::TranscodeBuffer buffer;
+  if (!stencilWorker.run([&](JSContext *cx) {
+        return JS::EncodeStencil(cx, stencil->getStencil(), buffer) ==
+               JS::TranscodeResult::Ok;
+      })) {
+    return result;
   }
 
-  FrontendContext *ctx = new FrontendContext(fc);
-  if (!ctx) {
-    return nullptr;
+  char *data = static_cast<char *>(malloc(buffer.length()));
+  if (!data) {
+    return result;
   }
-  return ctx;
-}
+  memcpy(data, buffer.begin(), buffer.length());
 
-void DestroyFrontendContext(FrontendContextPtr ctx) {
-  JS::DestroyFrontendContext(ctx->getJSFrontendContext());
-  delete ctx;
+  result.ok = true;
+  result.data = data;
+  result.len = buffer.length();
+  return result;
 }
 
-ResultCompileStencil CompileScriptToStencil(FrontendContextPtr ctx,
-                                            char *filename, char *code) {
+ResultCompileStencil DecodeStencil(char *data, int len, bool module) {
   ResultCompileStencil result = {};
 
-  JS::CompileOptions options(JS::CompileOptions::ForFrontendContext{});
-  options.setFileAndLine(filename, 1);
-
-  JS::SourceText<mozilla::Utf8Unit> source;
-  if (!source.init(ctx->getJSFrontendContext(), code, strlen(code),
-                   JS::SourceOwnership::Borrowed)) {
+  JS::FrontendContext *fc = JS::NewFrontendContext();
+  if (!fc) {
     return result;
   }
 
-  JS::CompilationStorage compileStorage;
-  RefPtr<JS::Stencil> st = JS::CompileGlobalScriptToStencil(
-      ctx->getJSFrontendContext(), options, source, compileStorage);
-  if (!st) {
+  JS::DecodeOptions options;
+  JS::TranscodeRange range(reinterpret_cast<const uint8_t *>(data), len);
+  JS::Stencil *st = nullptr;
+  JS::TranscodeResult res = JS::DecodeStencil(fc, options, range, &st);
+  JS::DestroyFrontendContext(fc);
+  if (res != JS::TranscodeResult::Ok || !st) {
     return result;
   }
 
-  Stencil *stencil = new Stencil(st);
+  Stencil *stencil = new Stencil(already_AddRefed<JS::Stencil>(st), module);
   if (!stencil) {
     return result;
   }
@@ -824,6 +2559,8 @@ ResultCompileStencil CompileScriptToStencil(FrontendContextPtr ctx,
   return result;
 }
 
+bool StencilIsModule(StencilPtr stencil) { return stencil->isModule(); }
+
 void ReleaseStencil(StencilPtr stencil) { delete stencil; }
 
 ResultValue NewValueUndefined(ContextPtr ctx) {
@@ -934,6 +2671,22 @@ ResultValue NewValueInt32(ContextPtr ctx, int32_t i) {
   return result;
 }
 
+ResultValue CopyValue(ValuePtr value) {
+  ResultValue result = {};
+
+  JS::RootedValue val(value->getContext()->getJSContext(),
+                      value->getJSValue());
+
+  Value *v = new Value(value->getContext(), val);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
 void ReleaseValue(ValuePtr value) { delete value; }
 
 ResultString ToString(ValuePtr value) {
@@ -1086,6 +2839,40 @@ ResultValue NewPlainObject(ContextPtr ctx) {
   return result;
 }
 
+ResultValue NewHostObject(ContextPtr ctx, unsigned handle) {
+  ResultValue result = {};
+
+  JS::PersistentRootedObject global(ctx->getJSContext(),
+                                    ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedObject obj(ctx->getJSContext(),
+                       JS_NewObject(ctx->getJSContext(), &HostObjectClass));
+  if (!obj) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS_SetReservedSlot(obj, static_cast<uint32_t>(HostObjectSlots::CONTEXT_REF),
+                     JS::PrivateUint32Value(ctx->getRef()));
+  JS_SetReservedSlot(obj, static_cast<uint32_t>(HostObjectSlots::HANDLE),
+                     JS::PrivateUint32Value(handle));
+  JS::RootedValue objectVal(ctx->getJSContext());
+  objectVal.setObject(*obj);
+
+  Value *v = new Value(ctx, objectVal);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
 ResultBool ObjectHasProperty(ValuePtr object, char *key) {
   ResultBool result = {};
 
@@ -1329,7 +3116,7 @@ Result ObjectDeleteElement(ValuePtr object, uint32_t index) {
   return result;
 }
 
-ResultValue NewFunction(ContextPtr ctx, char *name) {
+ResultValue NewFunction(ContextPtr ctx, char *name, unsigned handle) {
   ResultValue result = {};
 
   JS::PersistentRootedObject global(ctx->getJSContext(),
@@ -1342,7 +3129,8 @@ ResultValue NewFunction(ContextPtr ctx, char *name) {
 
   JS::RootedFunction func(
       ctx->getJSContext(),
-      JS_NewFunction(ctx->getJSContext(), &FunctionCallback, 0, 0, name));
+      js::NewFunctionWithReserved(ctx->getJSContext(), &FunctionCallback, 0, 0,
+                                  name));
   if (!func) {
     result.err = GetError(ctx->getJSContext());
     return result;
@@ -1353,6 +3141,11 @@ ResultValue NewFunction(ContextPtr ctx, char *name) {
     result.err = GetError(ctx->getJSContext());
     return result;
   }
+  if (!SetFunctionHandle(ctx->getJSContext(), funcObj, ctx->getRef(),
+                         handle)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
   JS::RootedValue funcValue(ctx->getJSContext());
   funcValue.setObject(*funcObj);
 
@@ -1430,6 +3223,44 @@ ResultUInt32 GetArrayObjectLength(ValuePtr array) {
   return result;
 }
 
+ResultValue NewProxyObject(ContextPtr ctx, ValuePtr target, ValuePtr handler) {
+  ResultValue result = {};
+
+  JS::RootedObject global(ctx->getJSContext(), ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedObject proxyCtor(ctx->getJSContext());
+  if (!JS_GetClassObject(ctx->getJSContext(), JSProto_Proxy, &proxyCtor)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedValue proxyCtorVal(ctx->getJSContext(),
+                               JS::ObjectValue(*proxyCtor));
+  JS::RootedValueArray<2> args(ctx->getJSContext());
+  args[0].set(target->getJSValue());
+  args[1].set(handler->getJSValue());
+  JS::RootedObject obj(ctx->getJSContext());
+  if (!JS::Construct(ctx->getJSContext(), proxyCtorVal, args, &obj)) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedValue objectVal(ctx->getJSContext());
+  objectVal.setObject(*obj);
+
+  Value *v = new Value(ctx, objectVal);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
 ResultValue NewMapObject(ContextPtr ctx) {
   ResultValue result = {};
 
@@ -2002,6 +3833,166 @@ ResultValue SetObjectEntries(ValuePtr set) {
   return result;
 }
 
+ResultPromise NewPromiseObject(ContextPtr ctx) {
+  ResultPromise result = {};
+
+  JS::RootedObject global(ctx->getJSContext(), ctx->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(ctx->getJSContext(), global);
+
+  JS::RootedObject obj(ctx->getJSContext(),
+                       JS::NewPromiseObject(ctx->getJSContext(), nullptr));
+  if (!obj) {
+    result.err = GetError(ctx->getJSContext());
+    return result;
+  }
+  JS::RootedValue val(ctx->getJSContext());
+  val.setObject(*obj);
+
+  Value *v = new Value(ctx, val);
+  if (!v) {
+    return result;
+  }
+  Value *resolver = new Value(ctx, val);
+  if (!resolver) {
+    delete v;
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  result.resolver = resolver;
+  return result;
+}
+
+Result ResolvePromiseObject(ValuePtr promise, ValuePtr value) {
+  Result result = {};
+
+  JS::RootedObject global(promise->getContext()->getJSContext(),
+                          promise->getContext()->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(promise->getContext()->getJSContext(), global);
+
+  JS::RootedObject promiseObj(promise->getContext()->getJSContext(),
+                              &promise->getJSValue().toObject());
+  if (!promiseObj) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+  JS::RootedValue val(promise->getContext()->getJSContext(),
+                      value->getJSValue());
+
+  if (!JS::ResolvePromise(promise->getContext()->getJSContext(), promiseObj,
+                          val)) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+
+  result.ok = true;
+  return result;
+}
+
+Result RejectPromiseObject(ValuePtr promise, ValuePtr value) {
+  Result result = {};
+
+  JS::RootedObject global(promise->getContext()->getJSContext(),
+                          promise->getContext()->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(promise->getContext()->getJSContext(), global);
+
+  JS::RootedObject promiseObj(promise->getContext()->getJSContext(),
+                              &promise->getJSValue().toObject());
+  if (!promiseObj) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+  JS::RootedValue val(promise->getContext()->getJSContext(),
+                      value->getJSValue());
+
+  if (!JS::RejectPromise(promise->getContext()->getJSContext(), promiseObj,
+                         val)) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+
+  result.ok = true;
+  return result;
+}
+
+bool ValueIsPromise(ValuePtr value) {
+  if (!value->getJSValue().isObject()) {
+    return false;
+  }
+  JS::RootedObject obj(value->getContext()->getJSContext(),
+                       &value->getJSValue().toObject());
+  return JS::IsPromiseObject(obj);
+}
+
+ResultUInt32 PromiseObjectState(ValuePtr promise) {
+  ResultUInt32 result = {};
+
+  JS::RootedObject global(promise->getContext()->getJSContext(),
+                          promise->getContext()->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(promise->getContext()->getJSContext(), global);
+
+  JS::RootedObject promiseObj(promise->getContext()->getJSContext(),
+                              &promise->getJSValue().toObject());
+  if (!promiseObj) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+
+  JS::PromiseState state = JS::GetPromiseState(promiseObj);
+
+  result.ok = true;
+  result.value = static_cast<uint32_t>(state);
+  return result;
+}
+
+ResultValue PromiseObjectResult(ValuePtr promise) {
+  ResultValue result = {};
+
+  JS::RootedObject global(promise->getContext()->getJSContext(),
+                          promise->getContext()->getGlobalJSObject());
+  if (!global) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+  JSAutoRealm ar(promise->getContext()->getJSContext(), global);
+
+  JS::RootedObject promiseObj(promise->getContext()->getJSContext(),
+                              &promise->getJSValue().toObject());
+  if (!promiseObj) {
+    result.err = GetError(promise->getContext()->getJSContext());
+    return result;
+  }
+
+  JS::RootedValue val(promise->getContext()->getJSContext(),
+                      JS::GetPromiseResult(promiseObj));
+
+  Value *v = new Value(promise->getContext(), val);
+  if (!v) {
+    return result;
+  }
+
+  result.ok = true;
+  result.ptr = v;
+  return result;
+}
+
 ResultValue JSONParse(ContextPtr ctx, const char *data) {
   ResultValue result = {};
 
@@ -23,12 +23,19 @@ struct ContextOptions {
   uint32_t gcMaxBytes;
   uint32_t gcIncrementalEnabled;
   uint32_t gcSliceTimeBudgetMs;
+  bool globalResolve;
 };
 typedef struct ContextOptions ContextOptions;
 
 typedef struct Script Script;
 typedef Script* ScriptPtr;
 
+typedef struct Module Module;
+typedef Module* ModulePtr;
+
+typedef struct DynamicImport DynamicImport;
+typedef DynamicImport* DynamicImportPtr;
+
 typedef struct Value Value;
 typedef Value* ValuePtr;
 
@@ -43,11 +50,26 @@ typedef struct FrontendContextOptions FrontendContextOptions;
 typedef struct Stencil Stencil;
 typedef Stencil* StencilPtr;
 
+enum ErrorKind {
+  ERROR_KIND_DEFAULT,
+  ERROR_KIND_INTERRUPTED,
+  ERROR_KIND_OUT_OF_MEMORY,
+  ERROR_KIND_STACK_OVERFLOW,
+  ERROR_KIND_SYNTAX,
+};
+typedef enum ErrorKind ErrorKind;
+
 struct Error {
   const char* message;
   const char* filename;
   int lineno;
+  int column;
   int number;
+  const char* name;
+  unsigned contextRef;
+  unsigned handle;
+  bool exception;
+  ErrorKind kind;
 };
 typedef struct Error Error;
 
@@ -86,6 +108,14 @@ struct ResultString {
 };
 typedef struct ResultString ResultString;
 
+struct ResultException {
+  bool ok;
+  Error err;
+  ValuePtr ptr;
+  char* stack;
+};
+typedef struct ResultException ResultException;
+
 struct ResultCompileScript {
   bool ok;
   Error err;
@@ -93,25 +123,65 @@ struct ResultCompileScript {
 };
 typedef struct ResultCompileScript ResultCompileScript;
 
+struct ResultCompileModule {
+  bool ok;
+  Error err;
+  ModulePtr ptr;
+};
+typedef struct ResultCompileModule ResultCompileModule;
+
+struct ResultCheckSyntax {
+  bool ok;
+  Error err;
+  unsigned tokenLength;
+};
+typedef struct ResultCheckSyntax ResultCheckSyntax;
+
 struct ResultCompileStencil {
   bool ok;
+  Error err;
   StencilPtr ptr;
 };
 typedef struct ResultCompileStencil ResultCompileStencil;
 
+struct ResultPromise {
+  bool ok;
+  Error err;
+  ValuePtr ptr;
+  ValuePtr resolver;
+};
+typedef struct ResultPromise ResultPromise;
+
 struct ResultGoFunctionCallback {
   ValuePtr ptr;
+  bool thrown;
   char* err;
+  char* errName;
+  ValuePtr errProps;
+  unsigned errHandle;
 };
 typedef struct ResultGoFunctionCallback ResultGoFunctionCallback;
 
+struct ResultGoModuleResolve {
+  ModulePtr ptr;
+  char* err;
+  char* errName;
+  unsigned errHandle;
+};
+typedef struct ResultGoModuleResolve ResultGoModuleResolve;
+
 bool Init();
 void ShutDown();
 const char* Version();
+const char* BuildId();
 
 ContextPtr NewContext(unsigned ref, ContextOptions options);
 void DestroyContext(ContextPtr ctx);
+ResultException TakeException(ContextPtr ctx);
+ResultException GetErrorCause(ValuePtr value);
 void RequestInterruptContext(ContextPtr ctx);
+void GCContext(ContextPtr ctx);
+Result RunJobs(ContextPtr ctx);
 ResultValue GetGlobalObject(ContextPtr ctx);
 ResultValue DefineObject(ContextPtr ctx, ValuePtr recv, char* name,
                          unsigned attrs);
@@ -119,8 +189,15 @@ Result DefineProperty(ContextPtr ctx, ValuePtr recv, char* name, ValuePtr value,
                       unsigned attrs);
 Result DefineElement(ContextPtr ctx, ValuePtr recv, uint32_t index,
                      ValuePtr value, unsigned attrs);
-Result DefineFunction(ContextPtr ctx, ValuePtr recv, char* name, unsigned nargs,
-                      unsigned attrs);
+Result DefineFunction(ContextPtr ctx, ValuePtr recv, char* name,
+                      unsigned handle, unsigned nargs, unsigned attrs);
+ResultValue DefineClass(ContextPtr ctx, ValuePtr recv, char* name,
+                        unsigned handle, unsigned nargs, unsigned attrs);
+Result DefineAccessor(ContextPtr ctx, ValuePtr recv, char* name,
+                      unsigned getter, unsigned setter, unsigned attrs);
+bool SetHostObjectData(ValuePtr value, unsigned handle);
+ResultUInt32 GetHostObjectData(ValuePtr value);
+ResultValue GetHostObjectCache(ValuePtr value);
 ResultValue CallFunctionName(ContextPtr ctx, char* name, ValuePtr recv,
                              int argc, ValuePtr* argv);
 ResultValue CallFunctionValue(ContextPtr ctx, ValuePtr func, ValuePtr recv,
@@ -130,11 +207,29 @@ ResultCompileScript CompileScript(ContextPtr ctx, char* filename, char* code);
 void ReleaseScript(ScriptPtr script);
 ResultValue ExecuteScript(ContextPtr ctx, ScriptPtr script);
 ResultValue ExecuteScriptFromStencil(ContextPtr ctx, StencilPtr stencil);
+ResultCompileModule CompileModule(ContextPtr ctx, char* filename, char* code);
+void ReleaseModule(ModulePtr module);
+Result LinkModule(ContextPtr ctx, ModulePtr module);
+ResultValue EvaluateModule(ContextPtr ctx, ModulePtr module);
+ResultValue GetModuleNamespaceObject(ContextPtr ctx, ModulePtr module);
+ResultCompileModule InstantiateModuleFromStencil(ContextPtr ctx,
+                                                 StencilPtr stencil);
+Result FinishDynamicImport(ContextPtr ctx, DynamicImportPtr imp,
+                           ModulePtr module);
+Result FailDynamicImport(ContextPtr ctx, DynamicImportPtr imp, char* message);
+void ReleaseDynamicImport(DynamicImportPtr imp);
 
 FrontendContextPtr NewFrontendContext(FrontendContextOptions options);
 void DestroyFrontendContext(FrontendContextPtr ctx);
 ResultCompileStencil CompileScriptToStencil(FrontendContextPtr ctx,
                                             char* filename, char* code);
+ResultCompileStencil CompileModuleToStencil(FrontendContextPtr ctx,
+                                            char* filename, char* code);
+ResultCheckSyntax CheckSyntax(FrontendContextPtr ctx, char* filename,
+                              char* code, bool module);
+ResultString EncodeStencil(StencilPtr stencil);
+ResultCompileStencil DecodeStencil(char* data, int len, bool module);
+bool StencilIsModule(StencilPtr stencil);
 void ReleaseStencil(StencilPtr stencil);
 
 ResultValue NewValueUndefined(ContextPtr ctx);
@@ -143,6 +238,7 @@ ResultValue NewValueString(ContextPtr ctx, char* str, int len);
 ResultValue NewValueBoolean(ContextPtr ctx, bool b);
 ResultValue NewValueNumber(ContextPtr ctx, double d);
 ResultValue NewValueInt32(ContextPtr ctx, int32_t i);
+ResultValue CopyValue(ValuePtr value);
 void ReleaseValue(ValuePtr value);
 ResultString ToString(ValuePtr value);
 bool ValueIs(ValuePtr value1, ValuePtr value2);
@@ -164,6 +260,7 @@ double ValueToNumber(ValuePtr value);
 int32_t ValueToInt32(ValuePtr value);
 
 ResultValue NewPlainObject(ContextPtr ctx);
+ResultValue NewHostObject(ContextPtr ctx, unsigned handle);
 ResultBool ObjectHasProperty(ValuePtr object, char* key);
 ResultValue ObjectGetProperty(ValuePtr object, char* key);
 Result ObjectSetProperty(ValuePtr object, char* key, ValuePtr value);
@@ -173,11 +270,13 @@ ResultValue ObjectGetElement(ValuePtr object, uint32_t index);
 Result ObjectSetElement(ValuePtr object, uint32_t index, ValuePtr value);
 Result ObjectDeleteElement(ValuePtr object, uint32_t index);
 
-ResultValue NewFunction(ContextPtr ctx, char* name);
+ResultValue NewFunction(ContextPtr ctx, char* name, unsigned handle);
 
 ResultValue NewArrayObject(ContextPtr ctx, int argc, ValuePtr* argv);
 ResultUInt32 GetArrayObjectLength(ValuePtr array);
 
+ResultValue NewProxyObject(ContextPtr ctx, ValuePtr target, ValuePtr handler);
+
 ResultValue NewMapObject(ContextPtr ctx);
 ResultUInt32 MapObjectSize(ValuePtr map);
 ResultBool MapObjectHas(ValuePtr map, ValuePtr key);
@@ -199,6 +298,13 @@ ResultValue SetObjectKeys(ValuePtr set);
 ResultValue SetObjectValues(ValuePtr set);
 ResultValue SetObjectEntries(ValuePtr set);
 
+ResultPromise NewPromiseObject(ContextPtr ctx);
+Result ResolvePromiseObject(ValuePtr promise, ValuePtr value);
+Result RejectPromiseObject(ValuePtr promise, ValuePtr value);
+bool ValueIsPromise(ValuePtr value);
+ResultUInt32 PromiseObjectState(ValuePtr promise);
+ResultValue PromiseObjectResult(ValuePtr promise);
+
 ResultValue JSONParse(ContextPtr ctx, const char* data);
 ResultString JSONStringify(ContextPtr ctx, ValuePtr value);
 
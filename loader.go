@@ -0,0 +1,224 @@
+package gomonkey
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ModuleLoader represents a loader of the modules imported by a context.
+type ModuleLoader interface {
+	// Resolve resolves the specifier imported by the referrer module into a module name.
+	Resolve(referrer string, specifier string) (string, error)
+	// Load returns the source code of the named module.
+	Load(name string) ([]byte, error)
+}
+
+// FSModuleLoader implements a module loader backed by a file system.
+type FSModuleLoader struct {
+	options fsModuleLoaderOptions
+	fsys    fs.FS
+}
+
+// fsModuleLoaderOptions implements the file system module loader options.
+type fsModuleLoaderOptions struct {
+	importMap *importMap
+}
+
+// FSModuleLoaderOptionFunc represents a file system module loader option function.
+type FSModuleLoaderOptionFunc func(l *FSModuleLoader) error
+
+// NewFSModuleLoader creates a new module loader resolving the modules from the given file system.
+//
+// The module names are the slash-separated paths of the module files in the file system. Relative specifiers are
+// resolved against the referrer module, absolute specifiers against the root of the file system.
+func NewFSModuleLoader(fsys fs.FS, options ...FSModuleLoaderOptionFunc) (*FSModuleLoader, error) {
+	loader := &FSModuleLoader{
+		fsys: fsys,
+	}
+
+	for _, option := range options {
+		if err := option(loader); err != nil {
+			return nil, err
+		}
+	}
+
+	return loader, nil
+}
+
+// WithImportMap sets the import map used to resolve the module specifiers.
+func WithImportMap(data []byte) FSModuleLoaderOptionFunc {
+	return func(l *FSModuleLoader) error {
+		m, err := parseImportMap(data)
+		if err != nil {
+			return err
+		}
+		l.options.importMap = m
+		return nil
+	}
+}
+
+// Resolve resolves the specifier imported by the referrer module into a module name.
+func (l *FSModuleLoader) Resolve(referrer string, specifier string) (string, error) {
+	normalized := specifier
+	if isURLLikeSpecifier(specifier) {
+		normalized = resolveURLLikeSpecifier("/"+referrer, specifier)
+	}
+
+	if l.options.importMap != nil {
+		if target, ok := l.options.importMap.resolve("/"+referrer, normalized); ok {
+			normalized = target
+		}
+	}
+	if !strings.HasPrefix(normalized, "/") {
+		return "", fmt.Errorf("bare specifier %q is not mapped", specifier)
+	}
+
+	name := strings.TrimPrefix(normalized, "/")
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("invalid module path %q", name)
+	}
+	return name, nil
+}
+
+// Load returns the source code of the named module.
+func (l *FSModuleLoader) Load(name string) ([]byte, error) {
+	data, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("read module: %w", err)
+	}
+	return data, nil
+}
+
+// importMap implements an import map.
+type importMap struct {
+	imports specifierMap
+	scopes  []importMapScope
+}
+
+// importMapScope implements an import map scope.
+type importMapScope struct {
+	prefix  string
+	imports specifierMap
+}
+
+// specifierMap implements a specifier map sorted by descending key length.
+type specifierMap []specifierMapEntry
+
+// specifierMapEntry implements a specifier map entry.
+type specifierMapEntry struct {
+	key    string
+	target string
+}
+
+// parseImportMap parses a JSON-encoded import map.
+func parseImportMap(data []byte) (*importMap, error) {
+	var raw struct {
+		Imports map[string]string            `json:"imports"`
+		Scopes  map[string]map[string]string `json:"scopes"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse import map: %w", err)
+	}
+
+	imports, err := newSpecifierMap(raw.Imports)
+	if err != nil {
+		return nil, err
+	}
+	m := &importMap{
+		imports: imports,
+	}
+	for prefix, rawImports := range raw.Scopes {
+		imports, err := newSpecifierMap(rawImports)
+		if err != nil {
+			return nil, err
+		}
+		m.scopes = append(m.scopes, importMapScope{
+			prefix:  resolveURLLikeSpecifier("/", prefix),
+			imports: imports,
+		})
+	}
+	sort.Slice(m.scopes, func(i, j int) bool {
+		return len(m.scopes[i].prefix) > len(m.scopes[j].prefix)
+	})
+
+	return m, nil
+}
+
+// newSpecifierMap creates a new specifier map from the raw import map entries.
+func newSpecifierMap(raw map[string]string) (specifierMap, error) {
+	m := make(specifierMap, 0, len(raw))
+	for key, target := range raw {
+		if key == "" {
+			return nil, errors.New("parse import map: empty specifier key")
+		}
+		if !isURLLikeSpecifier(target) {
+			return nil, fmt.Errorf("parse import map: invalid target %q", target)
+		}
+		if strings.HasSuffix(key, "/") != strings.HasSuffix(target, "/") {
+			return nil, fmt.Errorf("parse import map: invalid target %q for prefix %q", target, key)
+		}
+		if isURLLikeSpecifier(key) {
+			key = resolveURLLikeSpecifier("/", key)
+		}
+		m = append(m, specifierMapEntry{
+			key:    key,
+			target: resolveURLLikeSpecifier("/", target),
+		})
+	}
+	sort.Slice(m, func(i, j int) bool {
+		return len(m[i].key) > len(m[j].key)
+	})
+	return m, nil
+}
+
+// resolve resolves a normalized specifier imported from the referrer URL.
+func (m *importMap) resolve(referrer string, specifier string) (string, bool) {
+	for _, scope := range m.scopes {
+		if referrer != scope.prefix &&
+			!(strings.HasSuffix(scope.prefix, "/") && strings.HasPrefix(referrer, scope.prefix)) {
+			continue
+		}
+		if target, ok := scope.imports.resolve(specifier); ok {
+			return target, true
+		}
+	}
+	return m.imports.resolve(specifier)
+}
+
+// resolve resolves a normalized specifier.
+func (m specifierMap) resolve(specifier string) (string, bool) {
+	for _, entry := range m {
+		if entry.key == specifier {
+			return entry.target, true
+		}
+		if strings.HasSuffix(entry.key, "/") && strings.HasPrefix(specifier, entry.key) {
+			return resolveURLLikeSpecifier("/", entry.target+strings.TrimPrefix(specifier, entry.key)), true
+		}
+	}
+	return "", false
+}
+
+// isURLLikeSpecifier checks if a specifier is an absolute or relative path.
+func isURLLikeSpecifier(specifier string) bool {
+	return strings.HasPrefix(specifier, "/") || strings.HasPrefix(specifier, "./") ||
+		strings.HasPrefix(specifier, "../")
+}
+
+// resolveURLLikeSpecifier resolves a path specifier against the referrer URL.
+func resolveURLLikeSpecifier(referrer string, specifier string) string {
+	var p string
+	if strings.HasPrefix(specifier, "/") {
+		p = path.Clean(specifier)
+	} else {
+		p = path.Join(path.Dir(referrer), specifier)
+	}
+	if strings.HasSuffix(specifier, "/") && p != "/" {
+		p += "/"
+	}
+	return p
+}
@@ -0,0 +1,115 @@
+package gomonkey
+
+// #include "gomonkey.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// Module represents a JS module.
+type Module struct {
+	ptr C.ModulePtr
+	ctx *Context
+}
+
+// Release releases the module.
+func (m *Module) Release() {
+	C.ReleaseModule(m.ptr)
+}
+
+// Link links the module and its dependencies.
+func (m *Module) Link() error {
+	result := C.LinkModule(m.ctx.ptr, m.ptr)
+	if !result.ok {
+		return newJSError(result.err)
+	}
+	return nil
+}
+
+// Evaluate evaluates the linked module and returns its evaluation promise.
+//
+// The promise of a module using top-level await is settled only after running the pending jobs with Context.RunJobs.
+func (m *Module) Evaluate() (*Promise, error) {
+	result := C.EvaluateModule(m.ctx.ptr, m.ptr)
+	if !result.ok {
+		return nil, newJSError(result.err)
+	}
+	return &Promise{&Value{result.ptr, m.ctx}}, nil
+}
+
+// Namespace returns the module namespace object.
+func (m *Module) Namespace() (*Object, error) {
+	result := C.GetModuleNamespaceObject(m.ctx.ptr, m.ptr)
+	if !result.ok {
+		return nil, newJSError(result.err)
+	}
+	return &Object{&Value{result.ptr, m.ctx}}, nil
+}
+
+// DynamicImportCallback implements a dynamic import callback.
+//
+// The callback is called for each import() expression and must settle the dynamic import with Finish or Fail,
+// immediately or later from the goroutine of the context.
+type DynamicImportCallback func(ctx *Context, imp *DynamicImport)
+
+// ModuleMetadataCallback implements a callback populating the import.meta object of a module.
+type ModuleMetadataCallback func(ctx *Context, name string, meta *Object) error
+
+// moduleImport represents a module import.
+type moduleImport struct {
+	referrer  string
+	specifier string
+}
+
+// DynamicImport represents a pending dynamic import.
+type DynamicImport struct {
+	Referrer  string
+	Specifier string
+	ptr       C.DynamicImportPtr
+	ctx       *Context
+}
+
+// Finish links and evaluates the given module, and settles the dynamic import with its namespace.
+//
+// The context takes the ownership of the module which must not be released.
+func (i *DynamicImport) Finish(module *Module) error {
+	if i.ptr == nil {
+		return errors.New("dynamic import already settled")
+	}
+	i.ctx.registerImport(i.Referrer, i.Specifier, module)
+	return i.finish(module)
+}
+
+// Fail rejects the dynamic import with the given error.
+func (i *DynamicImport) Fail(reason error) error {
+	if i.ptr == nil {
+		return errors.New("dynamic import already settled")
+	}
+	cMessage := C.CString(reason.Error())
+	result := C.FailDynamicImport(i.ctx.ptr, i.ptr, cMessage)
+	C.free(unsafe.Pointer(cMessage))
+	i.release()
+	if !result.ok {
+		return newJSError(result.err)
+	}
+	return nil
+}
+
+// finish settles the dynamic import with the given module.
+func (i *DynamicImport) finish(module *Module) error {
+	result := C.FinishDynamicImport(i.ctx.ptr, i.ptr, module.ptr)
+	i.release()
+	if !result.ok {
+		return newJSError(result.err)
+	}
+	return nil
+}
+
+// release releases the settled dynamic import.
+func (i *DynamicImport) release() {
+	C.ReleaseDynamicImport(i.ptr)
+	i.ptr = nil
+	i.ctx.unregisterDynamicImport(i)
+}
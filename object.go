@@ -25,11 +25,58 @@ func NewObject(ctx *Context) (*Object, error) {
 	return &Object{&Value{result.ptr, ctx}}, nil
 }
 
+// HostDataFinalizer is implemented by the Go values of host objects to be notified of their finalization.
+type HostDataFinalizer interface {
+	// Finalize is called when the host object is collected by the JS garbage collector. The context must not be used
+	// during the call.
+	Finalize()
+}
+
+// NewHostObject creates a new host object carrying a Go value.
+//
+// The Go value is retrieved with HostData and released when the object is collected by the JS garbage collector.
+func NewHostObject(ctx *Context, data any) (*Object, error) {
+	handle := ctx.registerHostData(data)
+	result := C.NewHostObject(ctx.ptr, C.uint(handle))
+	if !result.ok {
+		ctx.unregisterHostData(handle)
+		return nil, newJSError(result.err)
+	}
+	return &Object{&Value{result.ptr, ctx}}, nil
+}
+
 // Release releases the object.
 func (o *Object) Release() {
 	C.ReleaseValue(o.v.ptr)
 }
 
+// HostData returns the Go value of a host object.
+func (o *Object) HostData() (any, error) {
+	result := C.GetHostObjectData(o.v.ptr)
+	if !result.ok {
+		return nil, errors.New("not a host object")
+	}
+	o.v.ctx.muHostData.RLock()
+	data, ok := o.v.ctx.hostData[uint(result.value)]
+	o.v.ctx.muHostData.RUnlock()
+	if !ok {
+		return nil, errors.New("invalid host object handle")
+	}
+	return data, nil
+}
+
+// hostCache returns the cache object of a host object.
+func (o *Object) hostCache() (*Object, error) {
+	result := C.GetHostObjectCache(o.v.ptr)
+	if !result.ok {
+		if result.err.message == nil {
+			return nil, errors.New("not a host object")
+		}
+		return nil, newJSError(result.err)
+	}
+	return &Object{&Value{result.ptr, o.v.ctx}}, nil
+}
+
 // Has checks if the object has the given property.
 func (o *Object) Has(key string) bool {
 	cKey := C.CString(key)
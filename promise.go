@@ -0,0 +1,118 @@
+package gomonkey
+
+// #include "gomonkey.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+)
+
+// Promise implements a JS promise object.
+type Promise struct {
+	v *Value
+}
+
+// PromiseState represents the state of a promise.
+type PromiseState uint8
+
+const (
+	PromiseStatePending PromiseState = iota
+	PromiseStateFulfilled
+	PromiseStateRejected
+)
+
+// String returns the state name.
+func (s PromiseState) String() string {
+	switch s {
+	case PromiseStatePending:
+		return "pending"
+	case PromiseStateFulfilled:
+		return "fulfilled"
+	case PromiseStateRejected:
+		return "rejected"
+	default:
+		return "unknown"
+	}
+}
+
+// PromiseResolver implements the resolving functions of a JS promise.
+type PromiseResolver struct {
+	v *Value
+}
+
+// NewPromise creates a new pending promise and its resolver.
+//
+// The promise can be returned to JS code while the resolver settles it later from Go. The pending jobs must then
+// be run with Context.RunJobs to execute the promise reactions.
+func NewPromise(ctx *Context) (*Promise, *PromiseResolver, error) {
+	result := C.NewPromiseObject(ctx.ptr)
+	if !result.ok {
+		return nil, nil, newJSError(result.err)
+	}
+	return &Promise{&Value{result.ptr, ctx}}, &PromiseResolver{&Value{result.resolver, ctx}}, nil
+}
+
+// Release releases the promise.
+func (p *Promise) Release() {
+	C.ReleaseValue(p.v.ptr)
+}
+
+// State returns the promise state.
+func (p *Promise) State() (PromiseState, error) {
+	result := C.PromiseObjectState(p.v.ptr)
+	if !result.ok {
+		return PromiseStatePending, newJSError(result.err)
+	}
+	return PromiseState(result.value), nil
+}
+
+// Result returns the fulfillment value or the rejection reason of a settled promise.
+func (p *Promise) Result() (*Value, error) {
+	state, err := p.State()
+	if err != nil {
+		return nil, err
+	}
+	if state == PromiseStatePending {
+		return nil, errors.New("promise is pending")
+	}
+	result := C.PromiseObjectResult(p.v.ptr)
+	if !result.ok {
+		return nil, newJSError(result.err)
+	}
+	return &Value{result.ptr, p.v.ctx}, nil
+}
+
+// AsValue casts as a JS value.
+func (p *Promise) AsValue() *Value {
+	return p.v
+}
+
+// AsObject casts as a JS object.
+func (p *Promise) AsObject() (*Object, error) {
+	return p.v.AsObject()
+}
+
+// Release releases the resolver.
+func (r *PromiseResolver) Release() {
+	C.ReleaseValue(r.v.ptr)
+}
+
+// Resolve resolves the promise with the given value.
+func (r *PromiseResolver) Resolve(value Valuer) error {
+	result := C.ResolvePromiseObject(r.v.ptr, value.AsValue().ptr)
+	if !result.ok {
+		return newJSError(result.err)
+	}
+	return nil
+}
+
+// Reject rejects the promise with the given reason.
+func (r *PromiseResolver) Reject(reason Valuer) error {
+	result := C.RejectPromiseObject(r.v.ptr, reason.AsValue().ptr)
+	if !result.ok {
+		return newJSError(result.err)
+	}
+	return nil
+}
+
+var _ Valuer = (*Promise)(nil)
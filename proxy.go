@@ -0,0 +1,182 @@
+package gomonkey
+
+// #include "gomonkey.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+)
+
+// ProxyHandler represents the Go traps of a JS proxy.
+//
+// The operations without a trap are forwarded to the target. The arguments of the traps are owned by the proxy and
+// must not be released.
+type ProxyHandler struct {
+	Get                      func(target *Object, key *Value, receiver *Value) (*Value, error)
+	Set                      func(target *Object, key *Value, value *Value, receiver *Value) (bool, error)
+	Has                      func(target *Object, key *Value) (bool, error)
+	DeleteProperty           func(target *Object, key *Value) (bool, error)
+	OwnKeys                  func(target *Object) ([]string, error)
+	GetOwnPropertyDescriptor func(target *Object, key *Value) (*Value, error)
+	Apply                    func(target *Object, this *Value, args []*Value) (*Value, error)
+}
+
+// NewProxy creates a new JS proxy of the target object with Go traps.
+//
+// If the target is nil, a new plain object is used, or a new function if the handler has an Apply trap.
+func NewProxy(ctx *Context, target *Object, handler ProxyHandler) (*Object, error) {
+	if target == nil {
+		var value *Value
+		if handler.Apply != nil {
+			fn, err := NewFunction(ctx, "", func(args []*Value) (*Value, error) {
+				return nil, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			value = fn.AsValue()
+		} else {
+			object, err := NewObject(ctx)
+			if err != nil {
+				return nil, err
+			}
+			value = object.AsValue()
+		}
+		defer value.Release()
+		target = &Object{value}
+	}
+
+	handlerObject, err := NewObject(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer handlerObject.Release()
+	for name, trap := range handler.traps(ctx) {
+		fn, err := NewFunctionWithCallInfo(ctx, name, trap)
+		if err != nil {
+			return nil, err
+		}
+		err = handlerObject.Set(name, fn.AsValue())
+		fn.Release()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := C.NewProxyObject(ctx.ptr, target.AsValue().ptr, handlerObject.AsValue().ptr)
+	if !result.ok {
+		return nil, newJSError(result.err)
+	}
+	return &Object{&Value{result.ptr, ctx}}, nil
+}
+
+// traps returns the JS trap functions of the handler.
+func (h ProxyHandler) traps(ctx *Context) map[string]CallInfoCallback {
+	traps := map[string]CallInfoCallback{}
+	if h.Get != nil {
+		traps["get"] = proxyTrap(3, func(target *Object, args []*Value) (*Value, error) {
+			return h.Get(target, args[1], args[2])
+		})
+	}
+	if h.Set != nil {
+		traps["set"] = proxyTrap(4, func(target *Object, args []*Value) (*Value, error) {
+			ok, err := h.Set(target, args[1], args[2], args[3])
+			if err != nil {
+				return nil, err
+			}
+			return NewValueBoolean(ctx, ok)
+		})
+	}
+	if h.Has != nil {
+		traps["has"] = proxyTrap(2, func(target *Object, args []*Value) (*Value, error) {
+			ok, err := h.Has(target, args[1])
+			if err != nil {
+				return nil, err
+			}
+			return NewValueBoolean(ctx, ok)
+		})
+	}
+	if h.DeleteProperty != nil {
+		traps["deleteProperty"] = proxyTrap(2, func(target *Object, args []*Value) (*Value, error) {
+			ok, err := h.DeleteProperty(target, args[1])
+			if err != nil {
+				return nil, err
+			}
+			return NewValueBoolean(ctx, ok)
+		})
+	}
+	if h.OwnKeys != nil {
+		traps["ownKeys"] = proxyTrap(1, func(target *Object, args []*Value) (*Value, error) {
+			keys, err := h.OwnKeys(target)
+			if err != nil {
+				return nil, err
+			}
+			values := make([]*Value, 0, len(keys))
+			defer func() {
+				for _, value := range values {
+					value.Release()
+				}
+			}()
+			for _, key := range keys {
+				value, err := NewValueString(ctx, key)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			array, err := NewArrayObject(ctx, values...)
+			if err != nil {
+				return nil, err
+			}
+			return array.AsValue(), nil
+		})
+	}
+	if h.GetOwnPropertyDescriptor != nil {
+		traps["getOwnPropertyDescriptor"] = proxyTrap(2, func(target *Object, args []*Value) (*Value, error) {
+			return h.GetOwnPropertyDescriptor(target, args[1])
+		})
+	}
+	if h.Apply != nil {
+		traps["apply"] = proxyTrap(3, func(target *Object, args []*Value) (*Value, error) {
+			list, err := args[2].AsObject()
+			if err != nil {
+				return nil, err
+			}
+			array := &ArrayObject{list.v}
+			length := int(array.Length())
+			values := make([]*Value, 0, length)
+			var result *Value
+			defer func() {
+				for _, value := range values {
+					if value != result { // released by the caller
+						value.Release()
+					}
+				}
+			}()
+			for i := 0; i < length; i++ {
+				value, err := list.GetElement(i)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+			result, err = h.Apply(target, args[1], values)
+			return result, err
+		})
+	}
+	return traps
+}
+
+// proxyTrap returns a JS trap function calling a Go trap with the target and the trap arguments.
+func proxyTrap(argc int, trap func(target *Object, args []*Value) (*Value, error)) CallInfoCallback {
+	return func(info *CallInfo) (*Value, error) {
+		if len(info.Args) < argc {
+			return nil, errors.New("invalid proxy trap arguments")
+		}
+		target, err := info.Args[0].AsObject()
+		if err != nil {
+			return nil, err
+		}
+		return trap(target, info.Args)
+	}
+}
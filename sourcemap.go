@@ -0,0 +1,292 @@
+package gomonkey
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SourceMap represents a source map mapping the positions of a generated code to its original sources.
+type SourceMap struct {
+	data     []byte
+	sources  []string
+	names    []string
+	mappings [][]sourceMapping
+}
+
+// sourceMapping implements a mapping of a generated column to an original position.
+type sourceMapping struct {
+	column       int
+	source       int
+	sourceLine   int
+	sourceColumn int
+	name         int
+}
+
+// SourcePosition represents an original position of a source map.
+//
+// The lines and columns are 1-based.
+type SourcePosition struct {
+	Source string
+	Line   int
+	Column int
+	Name   string
+}
+
+var sourceMaps map[string]*SourceMap = map[string]*SourceMap{}
+var muSourceMaps sync.RWMutex
+
+// RegisterSourceMap registers the source map of the named script or module for all the contexts.
+func RegisterSourceMap(name string, m *SourceMap) {
+	muSourceMaps.Lock()
+	sourceMaps[name] = m
+	muSourceMaps.Unlock()
+}
+
+// UnregisterSourceMap unregisters the source map of the named script or module for all the contexts.
+func UnregisterSourceMap(name string) {
+	muSourceMaps.Lock()
+	delete(sourceMaps, name)
+	muSourceMaps.Unlock()
+}
+
+// ParseSourceMap parses a JSON-encoded source map.
+func ParseSourceMap(data []byte) (*SourceMap, error) {
+	var raw struct {
+		Version    int               `json:"version"`
+		SourceRoot string            `json:"sourceRoot"`
+		Sources    []string          `json:"sources"`
+		Names      []string          `json:"names"`
+		Mappings   string            `json:"mappings"`
+		Sections   []json.RawMessage `json:"sections"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse source map: %w", err)
+	}
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("parse source map: unsupported version %d", raw.Version)
+	}
+	if raw.Sections != nil {
+		return nil, errors.New("parse source map: index maps are not supported")
+	}
+
+	m := &SourceMap{
+		data:    bytes.Clone(data),
+		sources: make([]string, len(raw.Sources)),
+		names:   raw.Names,
+	}
+	for i, source := range raw.Sources {
+		if raw.SourceRoot != "" && !strings.Contains(source, "://") && !strings.HasPrefix(source, "/") {
+			source = strings.TrimSuffix(raw.SourceRoot, "/") + "/" + source
+		}
+		m.sources[i] = source
+	}
+	mappings, err := decodeMappings(raw.Mappings, len(m.sources), len(m.names))
+	if err != nil {
+		return nil, fmt.Errorf("parse source map: %w", err)
+	}
+	m.mappings = mappings
+
+	return m, nil
+}
+
+// LoadSourceMap loads the source map referenced by the sourceMappingURL comment of the named code.
+//
+// Inline maps are decoded from their data URL. External maps are read from the file system, relative to the code name,
+// unless fsys is nil. It returns a nil source map if the code has no comment or if its URL is not a data URL or a
+// path.
+func LoadSourceMap(fsys fs.FS, name string, code []byte) (*SourceMap, error) {
+	ref := sourceMappingURL(code)
+	if ref == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(ref, "data:") {
+		data, err := decodeDataURL(ref)
+		if err != nil {
+			return nil, fmt.Errorf("load source map: %w", err)
+		}
+		return ParseSourceMap(data)
+	}
+	if fsys == nil || strings.Contains(ref, "://") {
+		return nil, nil
+	}
+
+	p, err := url.PathUnescape(ref)
+	if err != nil {
+		return nil, fmt.Errorf("load source map: %w", err)
+	}
+	p = strings.TrimPrefix(resolveURLLikeSpecifier("/"+name, p), "/")
+	data, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, fmt.Errorf("load source map: %w", err)
+	}
+	return ParseSourceMap(data)
+}
+
+// loadSourceMap loads the source map referenced by the named code, or returns nil if it cannot be loaded.
+func loadSourceMap(fsys fs.FS, name string, code []byte) *SourceMap {
+	m, err := LoadSourceMap(fsys, name, code)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+// Lookup returns the original position of a generated position.
+//
+// The lines and columns are 1-based.
+func (m *SourceMap) Lookup(line int, column int) (SourcePosition, bool) {
+	if line < 1 || line > len(m.mappings) {
+		return SourcePosition{}, false
+	}
+	mappings := m.mappings[line-1]
+	i := sort.Search(len(mappings), func(i int) bool {
+		return mappings[i].column > column-1
+	})
+	if i == 0 || mappings[i-1].source < 0 {
+		return SourcePosition{}, false
+	}
+
+	mapping := mappings[i-1]
+	pos := SourcePosition{
+		Source: m.sources[mapping.source],
+		Line:   mapping.sourceLine + 1,
+		Column: mapping.sourceColumn + 1,
+	}
+	if mapping.name >= 0 {
+		pos.Name = m.names[mapping.name]
+	}
+	return pos, true
+}
+
+// sourceMappingURL returns the URL of the last sourceMappingURL comment of the code.
+func sourceMappingURL(code []byte) string {
+	var ref string
+	for _, line := range bytes.Split(code, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		var comment []byte
+		switch {
+		case bytes.HasPrefix(line, []byte("//# sourceMappingURL=")),
+			bytes.HasPrefix(line, []byte("//@ sourceMappingURL=")):
+			comment = line[len("//# sourceMappingURL="):]
+		case bytes.HasPrefix(line, []byte("/*# sourceMappingURL=")) && bytes.HasSuffix(line, []byte("*/")):
+			comment = bytes.TrimSuffix(line[len("/*# sourceMappingURL="):], []byte("*/"))
+		default:
+			continue
+		}
+		if fields := bytes.Fields(comment); len(fields) > 0 {
+			ref = string(fields[0])
+		}
+	}
+	return ref
+}
+
+// decodeDataURL decodes the data of a data URL.
+func decodeDataURL(ref string) ([]byte, error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(ref, "data:"), ",")
+	if !ok {
+		return nil, errors.New("invalid data URL")
+	}
+	if strings.HasSuffix(header, ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	s, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// decodeMappings decodes the VLQ-encoded mappings of a source map.
+func decodeMappings(s string, sources int, names int) ([][]sourceMapping, error) {
+	var lines [][]sourceMapping
+	var source, sourceLine, sourceColumn, name int
+	for _, line := range strings.Split(s, ";") {
+		var mappings []sourceMapping
+		var column int
+		for _, segment := range strings.Split(line, ",") {
+			if segment == "" {
+				continue
+			}
+			fields, err := decodeVLQ(segment)
+			if err != nil {
+				return nil, err
+			}
+
+			column += fields[0]
+			mapping := sourceMapping{
+				column: column,
+				source: -1,
+				name:   -1,
+			}
+			switch len(fields) {
+			case 1:
+			case 4, 5:
+				source += fields[1]
+				sourceLine += fields[2]
+				sourceColumn += fields[3]
+				if source < 0 || source >= sources || sourceLine < 0 || sourceColumn < 0 {
+					return nil, fmt.Errorf("invalid mapping %q", segment)
+				}
+				mapping.source = source
+				mapping.sourceLine = sourceLine
+				mapping.sourceColumn = sourceColumn
+				if len(fields) == 5 {
+					name += fields[4]
+					if name < 0 || name >= names {
+						return nil, fmt.Errorf("invalid mapping %q", segment)
+					}
+					mapping.name = name
+				}
+			default:
+				return nil, fmt.Errorf("invalid mapping %q", segment)
+			}
+			if column < 0 {
+				return nil, fmt.Errorf("invalid mapping %q", segment)
+			}
+			mappings = append(mappings, mapping)
+		}
+		sort.SliceStable(mappings, func(i, j int) bool {
+			return mappings[i].column < mappings[j].column
+		})
+		lines = append(lines, mappings)
+	}
+	return lines, nil
+}
+
+// decodeVLQ decodes the base64 VLQ values of a mapping segment.
+func decodeVLQ(segment string) ([]int, error) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+	var values []int
+	var value, shift int
+	for i := 0; i < len(segment); i++ {
+		digit := strings.IndexByte(alphabet, segment[i])
+		if digit < 0 || shift > 30 {
+			return nil, fmt.Errorf("invalid mapping %q", segment)
+		}
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+		if value&1 != 0 {
+			values = append(values, -(value >> 1))
+		} else {
+			values = append(values, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, fmt.Errorf("invalid mapping %q", segment)
+	}
+	return values, nil
+}
@@ -3,13 +3,161 @@ package gomonkey
 // #include "gomonkey.h"
 // #include <stdlib.h>
 import "C"
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// stencilMagic is the header prefix of the encoded stencils.
+var stencilMagic = []byte("GMKS")
+
+const (
+	// stencilKindScript is the kind of the encoded script stencils.
+	stencilKindScript byte = iota
+	// stencilKindModule is the kind of the encoded module stencils.
+	stencilKindModule
+)
 
 // Stencil implements a JS stencil.
 type Stencil struct {
-	ptr C.StencilPtr
+	ptr       C.StencilPtr
+	name      string
+	sourceMap *SourceMap
+}
+
+// UnmarshalStencil decodes a stencil encoded by Stencil.MarshalBinary.
+//
+// The stencil is rejected if it was encoded by another version or build configuration of SpiderMonkey. The source
+// map encoded with the stencil is restored.
+func UnmarshalStencil(data []byte) (*Stencil, error) {
+	if !bytes.HasPrefix(data, stencilMagic) {
+		return nil, errors.New("decode stencil: invalid header")
+	}
+	version, data, ok := readStencilHeader(data[len(stencilMagic):])
+	if !ok {
+		return nil, errors.New("decode stencil: invalid header")
+	}
+	if version != Version() {
+		return nil, fmt.Errorf("decode stencil: version %s does not match engine version %s", version, Version())
+	}
+	id, data, ok := readStencilHeader(data)
+	if !ok || len(data) == 0 {
+		return nil, errors.New("decode stencil: invalid header")
+	}
+	if id != buildID() {
+		return nil, fmt.Errorf("decode stencil: build %s does not match engine build %s", id, buildID())
+	}
+	kind := data[0]
+	if kind != stencilKindScript && kind != stencilKindModule {
+		return nil, errors.New("decode stencil: invalid kind")
+	}
+	name, data, ok := readStencilBytes(data[1:])
+	if !ok {
+		return nil, errors.New("decode stencil: invalid header")
+	}
+	mapData, data, ok := readStencilBytes(data)
+	if !ok {
+		return nil, errors.New("decode stencil: invalid header")
+	}
+	var m *SourceMap
+	if len(mapData) > 0 {
+		var err error
+		if m, err = ParseSourceMap(mapData); err != nil {
+			return nil, fmt.Errorf("decode stencil: %w", err)
+		}
+	}
+	if len(data) == 0 {
+		return nil, errors.New("decode stencil: no data")
+	}
+
+	cData := C.CBytes(data)
+	result := C.DecodeStencil((*C.char)(cData), C.int(len(data)), C.bool(kind == stencilKindModule))
+	C.free(cData)
+	if !result.ok {
+		return nil, errors.New("decode stencil")
+	}
+	return &Stencil{ptr: result.ptr, name: string(name), sourceMap: m}, nil
+}
+
+// readStencilHeader reads a length-prefixed string of an encoded stencil header.
+func readStencilHeader(data []byte) (string, []byte, bool) {
+	if len(data) < 1 || len(data) < int(data[0])+1 {
+		return "", nil, false
+	}
+	n := int(data[0])
+	return string(data[1 : n+1]), data[n+1:], true
+}
+
+// readStencilBytes reads a varint-prefixed byte string of an encoded stencil header.
+func readStencilBytes(data []byte) ([]byte, []byte, bool) {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || n > uint64(len(data)-size) {
+		return nil, nil, false
+	}
+	return data[size : size+int(n)], data[size+int(n):], true
+}
+
+// buildID returns the identifier of the version and build configuration of SpiderMonkey.
+func buildID() string {
+	cID := C.BuildId()
+	id := C.GoString(cID)
+	C.free(unsafe.Pointer(cID))
+	return id
 }
 
 // Release releases the stencil.
 func (s *Stencil) Release() {
 	C.ReleaseStencil(s.ptr)
 }
+
+// SourceMap returns the source map of the compiled script or module, if any.
+func (s *Stencil) SourceMap() *SourceMap {
+	return s.sourceMap
+}
+
+// MarshalBinary encodes the stencil with the version and build configuration of SpiderMonkey, and its source map.
+//
+// The stencils are encoded one at a time by a JS context shared by all the calls, started on first use on a dedicated
+// thread and stopped by ShutDown.
+func (s *Stencil) MarshalBinary() ([]byte, error) {
+	result := C.EncodeStencil(s.ptr)
+	if !result.ok {
+		return nil, errors.New("encode stencil")
+	}
+	payload := C.GoBytes(unsafe.Pointer(result.data), result.len)
+	C.free(unsafe.Pointer(result.data))
+
+	version := Version()
+	id := buildID()
+	if len(id) > 255 {
+		return nil, errors.New("encode stencil: build identifier too long")
+	}
+	kind := stencilKindScript
+	if C.StencilIsModule(s.ptr) {
+		kind = stencilKindModule
+	}
+	var mapData []byte
+	if s.sourceMap != nil {
+		mapData = s.sourceMap.data
+	}
+	data := make([]byte, 0, len(stencilMagic)+len(version)+len(id)+len(s.name)+len(mapData)+3+
+		2*binary.MaxVarintLen64+len(payload))
+	data = append(data, stencilMagic...)
+	data = append(data, byte(len(version)))
+	data = append(data, version...)
+	data = append(data, byte(len(id)))
+	data = append(data, id...)
+	data = append(data, kind)
+	data = binary.AppendUvarint(data, uint64(len(s.name)))
+	data = append(data, s.name...)
+	data = binary.AppendUvarint(data, uint64(len(mapData)))
+	data = append(data, mapData...)
+	data = append(data, payload...)
+	return data, nil
+}
+
+var _ encoding.BinaryMarshaler = (*Stencil)(nil)
@@ -0,0 +1,282 @@
+package gomonkey_test_bind
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+type address struct {
+	City string `js:"city"`
+}
+
+type user struct {
+	Name     string            `js:"name"`
+	Age      int               `js:"age"`
+	Email    string            `js:"email,omitempty"`
+	ID       int               `js:"id,readonly"`
+	Tags     []string          `js:"tags"`
+	Address  address           `js:"address"`
+	Home     *address          `js:"home"`
+	Password string            `js:"-"`
+	Values   []*gomonkey.Value `js:"values"`
+	internal int
+}
+
+func (u *user) Greet(greeting string) string {
+	return greeting + " " + u.Name
+}
+
+func (u *user) Birthday() int {
+	u.Age++
+	return u.Age
+}
+
+func (u *user) Sum(values ...float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+func (u *user) Fail() error {
+	return errors.New("failure")
+}
+
+func (u *user) Move(a address) {
+	u.Address = a
+}
+
+func (u *user) Names(objects []*gomonkey.Object) (string, error) {
+	var names []string
+	for _, object := range objects {
+		value, err := object.Get("name")
+		if err != nil {
+			return "", err
+		}
+		names = append(names, value.String())
+		value.Release()
+	}
+	return strings.Join(names, ","), nil
+}
+
+func bindUser(t *testing.T, ctx *gomonkey.Context, u *user) {
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+	if err := gomonkey.Bind(ctx, global, "user", u); err != nil {
+		t.Fatalf("Bind() err = %v, want %v", err, nil)
+	}
+}
+
+func evaluate(t *testing.T, ctx *gomonkey.Context, code string) string {
+	value, err := ctx.Evaluate([]byte(code))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate(%q) err = %v, want %v", code, err, nil)
+	}
+	defer value.Release()
+	return value.String()
+}
+
+func TestBind(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	u := &user{Name: "John", Age: 30, ID: 1, Tags: []string{"a", "b"}, Address: address{City: "Paris"}}
+	bindUser(t, ctx, u)
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"user.name", "John"},
+		{"user.age + 1", "31"},
+		{"user.email", "undefined"},
+		{"user.Password", "undefined"},
+		{"user.internal", "undefined"},
+		{"user.tags.join('-')", "a-b"},
+		{"user.address.city", "Paris"},
+		{"user.Greet('Hello')", "Hello John"},
+		{"user.Sum(1, 2, 3.5)", "6.5"},
+		{"user.name = 'Jane'; user.name", "Jane"},
+		{"user.id = 2; user.id", "1"},
+		{"user.address.city = 'Lyon'; user.address.city", "Lyon"},
+		{"user.Birthday()", "31"},
+	}
+	for _, tt := range tests {
+		if got := evaluate(t, ctx, tt.code); got != tt.want {
+			t.Errorf("ctx.Evaluate(%q) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+	if u.Name != "Jane" || u.Age != 31 || u.ID != 1 || u.Address.City != "Lyon" {
+		t.Errorf("user = %+v", u)
+	}
+}
+
+func TestBind_Arguments(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	u := &user{}
+	bindUser(t, ctx, u)
+
+	evaluate(t, ctx, "user.Move({city: 'Berlin'})")
+	if u.Address.City != "Berlin" {
+		t.Errorf("u.Address.City = %s, want %s", u.Address.City, "Berlin")
+	}
+	evaluate(t, ctx, "user.tags = ['x', 'y', 'z']")
+	if strings.Join(u.Tags, ",") != "x,y,z" {
+		t.Errorf("u.Tags = %v, want %v", u.Tags, []string{"x", "y", "z"})
+	}
+
+	codes := []string{
+		"user.age = 'old'",
+		"user.age = 1.5",
+		"user.Greet(42)",
+		"user.Fail()",
+		"user.tags = {length: -1}",
+		"user.tags = {length: 1.5}",
+		"user.tags = {length: 1e12}",
+		"user.tags = {length: 'x'}",
+	}
+	for _, code := range codes {
+		value, err := ctx.Evaluate([]byte(code))
+		if err == nil {
+			value.Release()
+			t.Errorf("ctx.Evaluate(%q) err = %v, want an error", code, err)
+		}
+	}
+}
+
+func TestBind_Values(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	u := &user{}
+	bindUser(t, ctx, u)
+
+	if got := evaluate(t, ctx, "user.Names([{name: 'a'}, {name: 'b'}])"); got != "a,b" {
+		t.Errorf("ctx.Evaluate() = %s, want %s", got, "a,b")
+	}
+	value, err := ctx.Evaluate([]byte("user.values = [1, 2]"))
+	if err == nil {
+		value.Release()
+		t.Errorf("ctx.Evaluate() err = %v, want an error", err)
+	}
+	if u.Values != nil {
+		t.Errorf("u.Values = %v, want %v", u.Values, nil)
+	}
+}
+
+func TestBind_FieldIdentity(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	u := &user{Home: &address{City: "Paris"}}
+	bindUser(t, ctx, u)
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"user.address === user.address", "true"},
+		{"user.home === user.home", "true"},
+		{"globalThis.home = user.home; home === user.home", "true"},
+	}
+	for _, tt := range tests {
+		if got := evaluate(t, ctx, tt.code); got != tt.want {
+			t.Errorf("ctx.Evaluate(%q) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+
+	u.Home = &address{City: "Lyon"}
+	if got := evaluate(t, ctx, "home === user.home"); got != "false" {
+		t.Errorf("ctx.Evaluate(%q) = %s, want %s", "home === user.home", got, "false")
+	}
+	if got := evaluate(t, ctx, "user.home.city"); got != "Lyon" {
+		t.Errorf("ctx.Evaluate(%q) = %s, want %s", "user.home.city", got, "Lyon")
+	}
+}
+
+func TestBind_NotStruct(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	if err := gomonkey.Bind(ctx, global, "value", 42); err == nil {
+		t.Errorf("Bind() err = %v, want an error", err)
+	}
+	if err := gomonkey.Bind(ctx, global, "value", (*user)(nil)); err == nil {
+		t.Errorf("Bind() err = %v, want an error", err)
+	}
+}
+
+type invalidMethod struct{}
+
+func (invalidMethod) Split() (string, string) {
+	return "", ""
+}
+
+func TestBind_InvalidMethod(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	if err := gomonkey.Bind(ctx, global, "value", &invalidMethod{}); err == nil {
+		t.Errorf("Bind() err = %v, want an error", err)
+	}
+}
@@ -0,0 +1,155 @@
+package gomonkey_test_class
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+type counter struct {
+	value int32
+}
+
+func counterSpec(ctx *gomonkey.Context) gomonkey.ClassSpec {
+	data := func(info *gomonkey.CallInfo) *counter {
+		this, err := info.This.AsObject()
+		if err != nil {
+			return nil
+		}
+		data, err := this.HostData()
+		if err != nil {
+			return nil
+		}
+		return data.(*counter)
+	}
+
+	return gomonkey.ClassSpec{
+		Name: "Counter",
+		Constructor: func(info *gomonkey.CallInfo) (any, error) {
+			c := &counter{}
+			if len(info.Args) > 0 && info.Args[0].IsInt32() {
+				c.value = info.Args[0].ToInt32()
+			}
+			return c, nil
+		},
+		Args: 1,
+		Methods: map[string]gomonkey.CallInfoCallback{
+			"increment": func(info *gomonkey.CallInfo) (*gomonkey.Value, error) {
+				c := data(info)
+				c.value++
+				return gomonkey.NewValueInt32(ctx, c.value)
+			},
+		},
+		StaticMethods: map[string]gomonkey.CallInfoCallback{
+			"create": func(info *gomonkey.CallInfo) (*gomonkey.Value, error) {
+				return ctx.Evaluate([]byte(`new Counter(10)`))
+			},
+		},
+		Accessors: map[string]gomonkey.AccessorSpec{
+			"value": {
+				Get: func(info *gomonkey.CallInfo) (*gomonkey.Value, error) {
+					return gomonkey.NewValueInt32(ctx, data(info).value)
+				},
+				Set: func(info *gomonkey.CallInfo) (*gomonkey.Value, error) {
+					data(info).value = info.Args[0].ToInt32()
+					return nil, nil
+				},
+			},
+		},
+	}
+}
+
+func TestContextDefineClass(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	constructor, err := ctx.DefineClass(global, counterSpec(ctx))
+	if err != nil {
+		t.Fatalf("ctx.DefineClass() err = %v, want %v", err, nil)
+	}
+	defer constructor.Release()
+
+	tests := []struct {
+		code string
+		want int32
+	}{
+		{code: `(() => { const c = new Counter(1); c.increment(); return c.increment(); })()`, want: 3},
+		{code: `(() => { const c = new Counter(); c.value = 5; c.increment(); return c.value; })()`, want: 6},
+		{code: `Counter.create().increment()`, want: 11},
+		{code: `new Counter() instanceof Counter ? 1 : 0`, want: 1},
+		{code: `(() => { class Sub extends Counter {}; const s = new Sub(4); return s.increment(); })()`, want: 5},
+		{code: `Counter.length`, want: 1},
+	}
+	for _, tt := range tests {
+		result, err := ctx.Evaluate([]byte(tt.code))
+		if err != nil {
+			t.Errorf("ctx.Evaluate(%q) err = %v, want %v", tt.code, err, nil)
+			continue
+		}
+		if !result.IsInt32() || result.ToInt32() != tt.want {
+			t.Errorf("ctx.Evaluate(%q) = %v, want %v", tt.code, result, tt.want)
+		}
+		result.Release()
+	}
+}
+
+func TestContextDefineClass_WithoutNew(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	constructor, err := ctx.DefineClass(global, counterSpec(ctx))
+	if err != nil {
+		t.Fatal()
+	}
+	defer constructor.Release()
+
+	_, err = ctx.Evaluate([]byte(`Counter()`))
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("ctx.Evaluate() err = %v, want *gomonkey.JSError", err)
+	}
+	if jsErr.Name != "TypeError" {
+		t.Errorf("jsErr.Name = %s, want %s", jsErr.Name, "TypeError")
+	}
+
+	result, err := ctx.Evaluate([]byte(`try { Counter(); "" } catch (e) { e instanceof TypeError ? e.name : "" }`))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if result.String() != "TypeError" {
+		t.Errorf("e.name = %s, want %s", result.String(), "TypeError")
+	}
+}
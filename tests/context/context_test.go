@@ -1,6 +1,7 @@
 package gomonkey_test_context
 
 import (
+	"errors"
 	"os"
 	"runtime"
 	"testing"
@@ -50,6 +51,78 @@ func TestNewContext_WithOptions(t *testing.T) {
 	ctx.Destroy()
 }
 
+func TestNewContext_WithGlobalResolve(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var names []string
+	ctx, err := gomonkey.NewContext(gomonkey.WithGlobalResolve(func(ctx *gomonkey.Context, global *gomonkey.Object,
+		name string) error {
+		names = append(names, name)
+		if name != "lazy" {
+			return nil
+		}
+		value, err := gomonkey.NewValueNumber(ctx, 42)
+		if err != nil {
+			return err
+		}
+		defer value.Release()
+		return ctx.DefineProperty(global, name, value, 0)
+	}))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	result, err := ctx.Evaluate([]byte("lazy + lazy + Math.abs(-1)"))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if result.ToInt32() != 85 {
+		t.Errorf("result = %d, want %d", result.ToInt32(), 85)
+	}
+	resolved := 0
+	for _, name := range names {
+		if name == "lazy" {
+			resolved++
+		}
+	}
+	if resolved != 1 {
+		t.Errorf("resolved = %d, want %d", resolved, 1)
+	}
+
+	if _, err := ctx.Evaluate([]byte("unknown")); err == nil {
+		t.Errorf("ctx.Evaluate() err = %v, want an error", err)
+	}
+}
+
+func TestNewContext_WithGlobalResolve_Error(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext(gomonkey.WithGlobalResolve(func(ctx *gomonkey.Context, global *gomonkey.Object,
+		name string) error {
+		if name == "broken" {
+			return errors.New("resolve error")
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	_, err = ctx.Evaluate([]byte("broken"))
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("ctx.Evaluate() err = %v, want a JS error", err)
+	}
+	if jsErr.Message != "resolve error" {
+		t.Errorf("jsErr.Message = %q, want %q", jsErr.Message, "resolve error")
+	}
+}
+
 func TestContextDestroy(t *testing.T) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -94,12 +167,43 @@ func TestContextRequestInterrupt(t *testing.T) {
 	case <-time.After(100 * time.Millisecond):
 		ctx.RequestInterrupt()
 		err := <-errCh
-		if err == nil {
-			t.Fail()
+		if !errors.Is(err, gomonkey.ErrInterrupted) {
+			t.Errorf("ctx.Evaluate() err = %v, want %v", err, gomonkey.ErrInterrupted)
 		}
 	}
 }
 
+func TestContextRunJobs(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	value, err := ctx.Evaluate([]byte(`
+var result = "pending";
+Promise.resolve("test").then((v) => { result = v; });
+`))
+	if err != nil {
+		t.Fatal()
+	}
+	value.Release()
+
+	if err := ctx.RunJobs(); err != nil {
+		t.Errorf("ctx.RunJobs() err = %v, want %v", err, nil)
+	}
+	result, err := ctx.Evaluate([]byte(`result`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer result.Release()
+	if !result.IsString() || result.ToString() != "test" {
+		t.Fatal()
+	}
+}
+
 func TestContextGlobal(t *testing.T) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -295,6 +399,102 @@ func TestContextDefineFunction_Object(t *testing.T) {
 	}
 }
 
+func TestContextDefineGoFunc(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	describe := func(a int, b string) (map[string]any, error) {
+		if a < 0 {
+			return nil, errors.New("negative value")
+		}
+		return map[string]any{"value": a * 2, "label": b}, nil
+	}
+	if err := ctx.DefineGoFunc(global, "describe", describe); err != nil {
+		t.Errorf("ctx.DefineGoFunc() err = %v, want %v", err, nil)
+	}
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"describe.length", "2"},
+		{"const d = describe(21, 'answer'); d.label + ':' + d.value", "answer:42"},
+		{"try { describe(-1, 'x') } catch (e) { e.constructor.name + ': ' + e.message }", "Error: negative value"},
+		{"try { describe('a', 'x') } catch (e) { e.constructor.name + ': ' + e.message }",
+			"TypeError: argument 1: cannot convert string to int"},
+		{"try { describe(1, 2) } catch (e) { e.constructor.name + ': ' + e.message }",
+			"TypeError: argument 2: cannot convert number to string"},
+	}
+	for _, tt := range tests {
+		value, err := ctx.Evaluate([]byte(tt.code))
+		if err != nil {
+			t.Fatalf("ctx.Evaluate(%q) err = %v, want %v", tt.code, err, nil)
+		}
+		if got := value.String(); got != tt.want {
+			t.Errorf("ctx.Evaluate(%q) = %s, want %s", tt.code, got, tt.want)
+		}
+		value.Release()
+	}
+}
+
+func TestContextDefineGoFunc_NotFunction(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	if err := ctx.DefineGoFunc(global, "test", 42); err == nil {
+		t.Errorf("ctx.DefineGoFunc() err = %v, want an error", err)
+	}
+}
+
+func TestContextDefineGoFunc_InvalidResults(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	for _, fn := range []any{
+		func() (int, string) { return 0, "" },
+		func() (int, string, error) { return 0, "", nil },
+		func() (error, int) { return nil, 0 },
+		func() (error, error) { return nil, nil },
+	} {
+		if err := ctx.DefineGoFunc(global, "test", fn); err == nil {
+			t.Errorf("ctx.DefineGoFunc(%T) err = %v, want an error", fn, err)
+		}
+	}
+}
+
 func TestContextDefineElement(t *testing.T) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -323,6 +523,92 @@ func TestContextDefineElement(t *testing.T) {
 	}
 }
 
+func TestContextDefineAccessor(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+	config, err := ctx.DefineObject(global, "config", gomonkey.PropertyAttributeDefault)
+	if err != nil {
+		t.Fatal()
+	}
+	defer config.Release()
+
+	var feature int32 = 1
+	getter := func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+		return gomonkey.NewValueInt32(ctx, feature)
+	}
+	setter := func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+		if len(args) != 1 || !args[0].IsInt32() {
+			return nil, errors.New("invalid value")
+		}
+		feature = args[0].ToInt32()
+		return nil, nil
+	}
+	if err := ctx.DefineAccessor(config, "feature", getter, setter, gomonkey.PropertyAttributeDefault); err != nil {
+		t.Fatalf("ctx.DefineAccessor() err = %v, want %v", err, nil)
+	}
+
+	result, err := ctx.Evaluate([]byte(`config.feature = 2; config.feature + 1`))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if !result.IsInt32() || result.ToInt32() != 3 {
+		t.Errorf("result = %v, want %v", result, 3)
+	}
+	if feature != 2 {
+		t.Errorf("feature = %v, want %v", feature, 2)
+	}
+	if _, err := ctx.Evaluate([]byte(`config.feature = "invalid"`)); err == nil {
+		t.Errorf("ctx.Evaluate() err = %v, want error", err)
+	}
+}
+
+func TestContextDefineAccessor_ReadOnly(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	getter := func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+		return gomonkey.NewValueString(ctx, "test")
+	}
+	if err := ctx.DefineAccessor(global, "test", getter, nil, gomonkey.PropertyAttributeDefault); err != nil {
+		t.Fatalf("ctx.DefineAccessor() err = %v, want %v", err, nil)
+	}
+	if err := ctx.DefineAccessor(global, "none", nil, nil, gomonkey.PropertyAttributeDefault); err == nil {
+		t.Errorf("ctx.DefineAccessor() err = %v, want error", err)
+	}
+
+	result, err := ctx.Evaluate([]byte(`test = "other"; test`))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if !result.IsString() || result.ToString() != "test" {
+		t.Errorf("result = %v, want %v", result, "test")
+	}
+}
+
 func TestContextCallFunctionName_DefineFunction(t *testing.T) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -632,6 +918,98 @@ func TestContextExecuteScriptFromStencil(t *testing.T) {
 	stencil.Release()
 }
 
+func TestContextCompileModule(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	module, err := ctx.CompileModule("module.js", []byte(`export const test = "test";`))
+	if err != nil {
+		t.Errorf("ctx.CompileModule() err = %v, want %v", err, nil)
+	}
+	module.Release()
+}
+
+func TestContextInstantiateModuleFromStencil(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	fc, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	stencil, err := fc.CompileModuleToStencil("module.js", []byte(`export const test = "test";`))
+	if err != nil {
+		t.Fatal()
+	}
+	fc.Destroy()
+	defer stencil.Release()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	module, err := ctx.InstantiateModuleFromStencil(stencil)
+	if err != nil {
+		t.Fatalf("ctx.InstantiateModuleFromStencil() err = %v, want %v", err, nil)
+	}
+	defer module.Release()
+	if err := module.Link(); err != nil {
+		t.Fatal()
+	}
+	promise, err := module.Evaluate()
+	if err != nil {
+		t.Fatal()
+	}
+	promise.Release()
+	namespace, err := module.Namespace()
+	if err != nil {
+		t.Fatal()
+	}
+	defer namespace.Release()
+	value, err := namespace.Get("test")
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	if !value.IsString() || value.ToString() != "test" {
+		t.Errorf("value = %v, want %v", value, "test")
+	}
+}
+
+func TestContextInstantiateModuleFromStencil_Script(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	fc, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	stencil, err := fc.CompileScriptToStencil("script.js", []byte(`"test"`))
+	if err != nil {
+		t.Fatal()
+	}
+	fc.Destroy()
+	defer stencil.Release()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	if _, err := ctx.InstantiateModuleFromStencil(stencil); err == nil {
+		t.Errorf("ctx.InstantiateModuleFromStencil() err = %v, want error", err)
+	}
+}
+
 func TestNewFrontendContext(t *testing.T) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -689,3 +1067,72 @@ func TestFrontendContextCompileScript(t *testing.T) {
 	}
 	stencil.Release()
 }
+
+func TestFrontendContextCompileScript_SyntaxError(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	_, err = ctx.CompileScriptToStencil("script.js", []byte("const a = 1;\nconst b = ;"))
+	jsErr, ok := err.(*gomonkey.JSError)
+	if !ok {
+		t.Fatalf("ctx.CompileScriptToStencil() err = %T, want *gomonkey.JSError", err)
+	}
+	if jsErr.Message == "" {
+		t.Errorf("err.Message = %q, want message", jsErr.Message)
+	}
+	if jsErr.Filename != "script.js" {
+		t.Errorf("err.Filename = %s, want %s", jsErr.Filename, "script.js")
+	}
+	if jsErr.LineNumber != 2 {
+		t.Errorf("err.LineNumber = %d, want %d", jsErr.LineNumber, 2)
+	}
+	if jsErr.ColumnNumber != 11 {
+		t.Errorf("err.ColumnNumber = %d, want %d", jsErr.ColumnNumber, 11)
+	}
+	if jsErr.Name != "SyntaxError" {
+		t.Errorf("err.Name = %s, want %s", jsErr.Name, "SyntaxError")
+	}
+}
+
+func TestFrontendContextCompileModule(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	stencil, err := ctx.CompileModuleToStencil("module.js", []byte(`export const test = "test";`))
+	if err != nil {
+		t.Errorf("ctx.CompileModuleToStencil() err = %v, want %v", err, nil)
+	}
+	stencil.Release()
+}
+
+func TestFrontendContextCompileModule_SyntaxError(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	_, err = ctx.CompileModuleToStencil("module.js", []byte(`export const = "test";`))
+	jsErr, ok := err.(*gomonkey.JSError)
+	if !ok {
+		t.Fatalf("ctx.CompileModuleToStencil() err = %T, want *gomonkey.JSError", err)
+	}
+	if jsErr.Filename != "module.js" || jsErr.LineNumber != 1 {
+		t.Errorf("err = %s:%d, want %s:%d", jsErr.Filename, jsErr.LineNumber, "module.js", 1)
+	}
+}
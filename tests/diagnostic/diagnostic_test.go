@@ -0,0 +1,119 @@
+package gomonkey_test_diagnostic
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+func TestFrontendContextCheckSyntax(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	diagnostics, err := ctx.CheckSyntax("script.js", []byte(`(() => { return "test"; })()`))
+	if err != nil {
+		t.Errorf("ctx.CheckSyntax() err = %v, want %v", err, nil)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("ctx.CheckSyntax() = %v, want %v", diagnostics, nil)
+	}
+}
+
+func TestFrontendContextCheckSyntax_Error(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	diagnostics, err := ctx.CheckSyntax("script.js", []byte("const a = 1;\nconst b = a + return;"))
+	if err != nil {
+		t.Fatalf("ctx.CheckSyntax() err = %v, want %v", err, nil)
+	}
+	if len(diagnostics) != 1 {
+		t.Fatalf("ctx.CheckSyntax() = %v, want 1 diagnostic", diagnostics)
+	}
+	d := diagnostics[0]
+	if d.Kind != "SyntaxError" {
+		t.Errorf("d.Kind = %s, want %s", d.Kind, "SyntaxError")
+	}
+	if d.Message == "" {
+		t.Errorf("d.Message = %q, want message", d.Message)
+	}
+	if d.Filename != "script.js" {
+		t.Errorf("d.Filename = %s, want %s", d.Filename, "script.js")
+	}
+	if d.Line != 2 || d.Column != 15 {
+		t.Errorf("d position = %d:%d, want %d:%d", d.Line, d.Column, 2, 15)
+	}
+	if d.EndLine != 2 || d.EndColumn != 21 {
+		t.Errorf("d end position = %d:%d, want %d:%d", d.EndLine, d.EndColumn, 2, 21)
+	}
+}
+
+func TestFrontendContextCheckSyntax_StackOverflow(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	code := strings.Repeat("[", 1000000) + strings.Repeat("]", 1000000)
+	diagnostics, err := ctx.CheckSyntax("script.js", []byte(code))
+	if !errors.Is(err, gomonkey.ErrStackOverflow) {
+		t.Errorf("ctx.CheckSyntax() err = %v, want %v", err, gomonkey.ErrStackOverflow)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("ctx.CheckSyntax() = %v, want %v", diagnostics, nil)
+	}
+}
+
+func TestFrontendContextCheckModuleSyntax(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	diagnostics, err := ctx.CheckModuleSyntax("module.js", []byte(`export const test = "test";`))
+	if err != nil {
+		t.Errorf("ctx.CheckModuleSyntax() err = %v, want %v", err, nil)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("ctx.CheckModuleSyntax() = %v, want %v", diagnostics, nil)
+	}
+
+	diagnostics, err = ctx.CheckSyntax("script.js", []byte(`export const test = "test";`))
+	if err != nil {
+		t.Errorf("ctx.CheckSyntax() err = %v, want %v", err, nil)
+	}
+	if len(diagnostics) != 1 {
+		t.Errorf("ctx.CheckSyntax() = %v, want 1 diagnostic", diagnostics)
+	}
+}
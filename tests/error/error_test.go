@@ -1,6 +1,7 @@
 package gomonkey_test_error
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
@@ -72,3 +73,397 @@ func TestErrorFormat(t *testing.T) {
 		t.Errorf("err.message = %s, want %s", q, "test message")
 	}
 }
+
+func TestCallbackError(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	errs := map[string]error{
+		"typeError":  &gomonkey.CallbackError{Name: "TypeError", Message: "invalid type"},
+		"rangeError": &gomonkey.CallbackError{Name: "RangeError", Message: "out of range"},
+		"notFound": fmt.Errorf("lookup: %w", &gomonkey.CallbackError{
+			Name:       "NotFoundError",
+			Message:    "user not found",
+			Properties: map[string]any{"code": "NOT_FOUND", "status": 404},
+		}),
+	}
+	for name, e := range errs {
+		e := e
+		if err := ctx.DefineFunction(global, name, func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+			return nil, e
+		}, 0, gomonkey.PropertyAttributeDefault); err != nil {
+			t.Fatal()
+		}
+	}
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"try { typeError() } catch (e) { (e instanceof TypeError) + ':' + e.message }", "true:invalid type"},
+		{"try { rangeError() } catch (e) { (e instanceof RangeError) + ':' + e.message }", "true:out of range"},
+		{"try { notFound() } catch (e) { [e instanceof Error, e.name, e.message, e.code, e.status].join(':') }",
+			"true:NotFoundError:user not found:NOT_FOUND:404"},
+	}
+	for _, tt := range tests {
+		value, err := ctx.Evaluate([]byte(tt.code))
+		if err != nil {
+			t.Fatalf("ctx.Evaluate(%q) err = %v, want %v", tt.code, err, nil)
+		}
+		if got := value.String(); got != tt.want {
+			t.Errorf("ctx.Evaluate(%q) = %s, want %s", tt.code, got, tt.want)
+		}
+		value.Release()
+	}
+}
+
+func TestThrow(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	if err := ctx.DefineFunction(global, "fail", func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+		value, err := gomonkey.NewValueString(ctx, "thrown value")
+		if err != nil {
+			return nil, err
+		}
+		defer value.Release()
+		return nil, gomonkey.Throw(value)
+	}, 0, gomonkey.PropertyAttributeDefault); err != nil {
+		t.Fatal()
+	}
+	if err := ctx.DefineFunction(global, "rethrow", func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+		return nil, gomonkey.Throw(args[0])
+	}, 1, gomonkey.PropertyAttributeDefault); err != nil {
+		t.Fatal()
+	}
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"try { fail() } catch (e) { typeof e + ':' + e }", "string:thrown value"},
+		{"const o = {code: 42}; try { rethrow(o) } catch (e) { e === o }", "true"},
+	}
+	for _, tt := range tests {
+		value, err := ctx.Evaluate([]byte(tt.code))
+		if err != nil {
+			t.Fatalf("ctx.Evaluate(%q) err = %v, want %v", tt.code, err, nil)
+		}
+		if got := value.String(); got != tt.want {
+			t.Errorf("ctx.Evaluate(%q) = %s, want %s", tt.code, got, tt.want)
+		}
+		value.Release()
+	}
+}
+
+var errNotFound = errors.New("not found")
+
+func TestErrorUnwrap(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	if err := ctx.DefineFunction(global, "find", func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+		return nil, fmt.Errorf("find user: %w", errNotFound)
+	}, 0, gomonkey.PropertyAttributeDefault); err != nil {
+		t.Fatal()
+	}
+
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"find()", true},
+		{"try { find() } catch (e) { throw e }", true},
+		{"try { find() } catch (e) { throw new Error(e.message) }", false},
+	}
+	for _, tt := range tests {
+		result, err := ctx.Evaluate([]byte(tt.code))
+		if err == nil {
+			result.Release()
+			t.Fatalf("ctx.Evaluate(%q) err = %v, want an error", tt.code, err)
+		}
+		var jsErr *gomonkey.JSError
+		if !errors.As(err, &jsErr) {
+			t.Errorf("ctx.Evaluate(%q) err type = %T, want *gomonkey.JSError", tt.code, err)
+		}
+		if got := errors.Is(err, errNotFound); got != tt.want {
+			t.Errorf("errors.Is(%v, errNotFound) = %t, want %t", err, got, tt.want)
+		}
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var panics []*gomonkey.PanicError
+	ctx, err := gomonkey.NewContext(gomonkey.WithPanicHandler(func(ctx *gomonkey.Context, err *gomonkey.PanicError) {
+		panics = append(panics, err)
+	}))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	if err := ctx.DefineFunction(global, "crash", func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+		panic("boom")
+	}, 0, gomonkey.PropertyAttributeDefault); err != nil {
+		t.Fatal()
+	}
+
+	value, err := ctx.Evaluate([]byte(
+		"try { crash() } catch (e) { [e instanceof InternalError, e.message, typeof e.goStack].join(':') }"))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	if got, want := value.String(), "true:panic: boom:string"; got != want {
+		t.Errorf("ctx.Evaluate() = %s, want %s", got, want)
+	}
+	value.Release()
+
+	result, err := ctx.Evaluate([]byte("crash()"))
+	if err == nil {
+		result.Release()
+		t.Fatalf("ctx.Evaluate() err = %v, want an error", err)
+	}
+	var panicErr *gomonkey.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("ctx.Evaluate() err = %v, want a *gomonkey.PanicError", err)
+	}
+	if panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Errorf("panicErr = %+v", panicErr)
+	}
+	if len(panics) != 2 {
+		t.Errorf("len(panics) = %d, want %d", len(panics), 2)
+	}
+}
+
+func TestPanicError_Hooks(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var panics int
+	ctx, err := gomonkey.NewContext(
+		gomonkey.WithGlobalResolve(func(ctx *gomonkey.Context, global *gomonkey.Object, name string) error {
+			if name == "crash" {
+				panic("boom")
+			}
+			return nil
+		}),
+		gomonkey.WithPanicHandler(func(ctx *gomonkey.Context, err *gomonkey.PanicError) {
+			panics++
+			panic("handler")
+		}),
+	)
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	value, err := ctx.Evaluate([]byte("try { crash } catch (e) { e.message }"))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	if got, want := value.String(), "panic: boom"; got != want {
+		t.Errorf("ctx.Evaluate() = %s, want %s", got, want)
+	}
+	value.Release()
+	if panics != 1 {
+		t.Errorf("panics = %d, want %d", panics, 1)
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext(gomonkey.WithErrorValues())
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	result, err := ctx.Evaluate([]byte(`
+function inner() { throw new TypeError("invalid", { cause: new RangeError("out of range") }); }
+function outer() { inner(); }
+outer();
+`))
+	if err == nil {
+		result.Release()
+		t.Fatalf("ctx.Evaluate() err = %v, want an error", err)
+	}
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("ctx.Evaluate() err type = %T, want *gomonkey.JSError", err)
+	}
+	defer jsErr.Release()
+
+	if jsErr.Name != "TypeError" || jsErr.Message != "invalid" {
+		t.Errorf("jsErr = %s: %s, want %s: %s", jsErr.Name, jsErr.Message, "TypeError", "invalid")
+	}
+	if jsErr.LineNumber != 2 || jsErr.ColumnNumber == 0 {
+		t.Errorf("jsErr location = %d:%d", jsErr.LineNumber, jsErr.ColumnNumber)
+	}
+	if len(jsErr.Stack) < 2 || jsErr.Stack[0].Function != "inner" || jsErr.Stack[1].Function != "outer" {
+		t.Errorf("jsErr.Stack = %v", jsErr.Stack)
+	} else if jsErr.Stack[0].LineNumber != 2 || jsErr.Stack[1].LineNumber != 3 {
+		t.Errorf("jsErr.Stack = %v", jsErr.Stack)
+	}
+	if jsErr.Value == nil || !jsErr.Value.IsObject() {
+		t.Errorf("jsErr.Value = %v", jsErr.Value)
+	}
+	if jsErr.Cause == nil {
+		t.Fatalf("jsErr.Cause = %v", jsErr.Cause)
+	}
+	if jsErr.Cause.Name != "RangeError" || jsErr.Cause.Message != "out of range" {
+		t.Errorf("jsErr.Cause = %s: %s, want %s: %s", jsErr.Cause.Name, jsErr.Cause.Message, "RangeError",
+			"out of range")
+	}
+	if errors.Unwrap(err) != nil {
+		t.Errorf("errors.Unwrap(err) = %v, want %v", errors.Unwrap(err), nil)
+	}
+}
+
+func TestErrorValue(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext(gomonkey.WithErrorValues())
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	result, err := ctx.Evaluate([]byte(`throw 42;`))
+	if err == nil {
+		result.Release()
+		t.Fatalf("ctx.Evaluate() err = %v, want an error", err)
+	}
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("ctx.Evaluate() err type = %T, want *gomonkey.JSError", err)
+	}
+	if jsErr.Value == nil || !jsErr.Value.IsNumber() || jsErr.Value.ToInt32() != 42 {
+		t.Errorf("jsErr.Value = %v, want %d", jsErr.Value, 42)
+	}
+	jsErr.Release()
+	if jsErr.Value != nil {
+		t.Errorf("jsErr.Value = %v, want %v", jsErr.Value, nil)
+	}
+}
+
+func TestErrorValue_Disabled(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	result, err := ctx.Evaluate([]byte(`throw new Error("boom", { cause: 42 });`))
+	if err == nil {
+		result.Release()
+		t.Fatalf("ctx.Evaluate() err = %v, want an error", err)
+	}
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("ctx.Evaluate() err type = %T, want *gomonkey.JSError", err)
+	}
+	if jsErr.Value != nil {
+		t.Errorf("jsErr.Value = %v, want %v", jsErr.Value, nil)
+	}
+	if jsErr.Message != "boom" || len(jsErr.Stack) == 0 || jsErr.Cause == nil {
+		t.Errorf("jsErr = %+v", jsErr)
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"var x = ;", gomonkey.ErrSyntax},
+		{"function f() { return f(); } f();", gomonkey.ErrStackOverflow},
+	}
+	for _, tt := range tests {
+		result, err := ctx.Evaluate([]byte(tt.code))
+		if err == nil {
+			result.Release()
+			t.Fatalf("ctx.Evaluate(%q) err = %v, want an error", tt.code, err)
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("errors.Is(%v, %v) = %t, want %t", err, tt.want, false, true)
+		}
+		if errors.Is(err, gomonkey.ErrInterrupted) || errors.Is(err, gomonkey.ErrOutOfMemory) {
+			t.Errorf("errors.Is(%v) matches an unexpected sentinel", err)
+		}
+	}
+
+	codes := []string{
+		`throw new Error("failure");`,
+		`throw new SyntaxError("failure");`,
+		`JSON.parse("{");`,
+	}
+	for _, code := range codes {
+		result, err := ctx.Evaluate([]byte(code))
+		if err == nil {
+			result.Release()
+			t.Fatalf("ctx.Evaluate(%q) err = %v, want an error", code, err)
+		}
+		for _, target := range []error{gomonkey.ErrInterrupted, gomonkey.ErrOutOfMemory, gomonkey.ErrStackOverflow,
+			gomonkey.ErrSyntax} {
+			if errors.Is(err, target) {
+				t.Errorf("errors.Is(%v, %v) = %t, want %t", err, target, true, false)
+			}
+		}
+	}
+}
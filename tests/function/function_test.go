@@ -3,7 +3,9 @@ package gomonkey_test_function
 import (
 	"os"
 	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/bhuisgen/gomonkey"
 )
@@ -126,3 +128,179 @@ func TestFunctionAsValue(t *testing.T) {
 		t.Errorf("f.AsValue() = %v", val)
 	}
 }
+
+func TestFunctionCall_SameName(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	newFunction := func(ret int32) *gomonkey.Function {
+		fn, err := gomonkey.NewFunction(ctx, "test", func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+			return gomonkey.NewValueInt32(ctx, ret)
+		})
+		if err != nil {
+			t.Fatal()
+		}
+		return fn
+	}
+	fn1 := newFunction(1)
+	defer fn1.Release()
+	fn2 := newFunction(2)
+	defer fn2.Release()
+
+	for want, fn := range map[int32]*gomonkey.Function{1: fn1, 2: fn2} {
+		result, err := fn.Call(fn.AsValue())
+		if err != nil {
+			t.Fatalf("f.Call() error = %v, want %v", err, nil)
+		}
+		if !result.IsInt32() || result.ToInt32() != want {
+			t.Errorf("f.Call() = %v, want %v", result, want)
+		}
+		result.Release()
+	}
+}
+
+func TestFunctionCall_DefineFunctionSameName(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	for _, name := range []string{"a", "b"} {
+		name := name
+		object, err := ctx.DefineObject(global, name, gomonkey.PropertyAttributeDefault)
+		if err != nil {
+			t.Fatal()
+		}
+		err = ctx.DefineFunction(object, "test", func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+			return gomonkey.NewValueString(ctx, name)
+		}, 0, gomonkey.PropertyAttributeDefault)
+		object.Release()
+		if err != nil {
+			t.Fatal()
+		}
+	}
+
+	result, err := ctx.Evaluate([]byte(`a.test() + b.test() + (() => { const f = a.test; return f(); })()`))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if !result.IsString() || result.ToString() != "aba" {
+		t.Errorf("result = %v, want %v", result, "aba")
+	}
+}
+
+func TestFunctionCall_GarbageCollected(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	// each callback captures a Go object finalized once the callback is unregistered
+	const n = 100
+	var finalized atomic.Int32
+	for i := 0; i < n; i++ {
+		captured := new(int)
+		runtime.SetFinalizer(captured, func(*int) {
+			finalized.Add(1)
+		})
+		fn, err := gomonkey.NewFunction(ctx, "", func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+			*captured++
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal()
+		}
+		result, err := fn.Call(fn.AsValue())
+		if err != nil {
+			t.Fatalf("f.Call() error = %v, want %v", err, nil)
+		}
+		result.Release()
+		fn.Release()
+	}
+
+	ctx.GC()
+	for i := 0; i < 50 && finalized.Load() < n/2; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := finalized.Load(); got < n/2 {
+		t.Errorf("finalized callbacks = %d, want at least %d", got, n/2)
+	}
+}
+
+func TestNewFunctionWithCallInfo(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	receiver, err := gomonkey.NewObject(ctx)
+	if err != nil {
+		t.Fatal()
+	}
+	defer receiver.Release()
+	name, err := gomonkey.NewValueString(ctx, "receiver")
+	if err != nil {
+		t.Fatal()
+	}
+	defer name.Release()
+	if err := receiver.Set("name", name); err != nil {
+		t.Fatal()
+	}
+
+	fn, err := gomonkey.NewFunctionWithCallInfo(ctx, "test", func(info *gomonkey.CallInfo) (*gomonkey.Value, error) {
+		if info.Context != ctx {
+			t.Errorf("info.Context = %v, want %v", info.Context, ctx)
+		}
+		if !info.NewTarget.IsUndefined() {
+			t.Errorf("info.NewTarget = %v, want undefined", info.NewTarget)
+		}
+		if !info.Callee.AsValue().IsFunction() {
+			t.Errorf("info.Callee = %v, want function", info.Callee)
+		}
+		if len(info.Args) != 1 {
+			t.Errorf("len(info.Args) = %d, want %d", len(info.Args), 1)
+		}
+		this, err := info.This.AsObject()
+		if err != nil {
+			t.Fatalf("info.This = %v, want object", info.This)
+		}
+		return this.Get("name")
+	})
+	if err != nil {
+		t.Fatalf("NewFunctionWithCallInfo() err = %v, want %v", err, nil)
+	}
+	defer fn.Release()
+
+	result, err := fn.Call(receiver, name)
+	if err != nil {
+		t.Fatalf("f.Call() error = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if !result.IsString() || result.ToString() != "receiver" {
+		t.Errorf("f.Call() = %v, want %v", result, "receiver")
+	}
+}
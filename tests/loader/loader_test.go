@@ -0,0 +1,259 @@
+package gomonkey_test_loader
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+func TestNewFSModuleLoader(t *testing.T) {
+	loader, err := gomonkey.NewFSModuleLoader(fstest.MapFS{})
+	if err != nil {
+		t.Errorf("NewFSModuleLoader() err = %v, want %v", err, nil)
+	}
+	if loader == nil {
+		t.Errorf("NewFSModuleLoader() = %v", loader)
+	}
+}
+
+func TestNewFSModuleLoader_WithImportMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			data: `{"imports": {"lib": "/lib/index.js", "lib/": "/lib/"}}`,
+		},
+		{
+			name:    "invalid JSON",
+			data:    `{`,
+			wantErr: true,
+		},
+		{
+			name:    "bare target",
+			data:    `{"imports": {"lib": "lib/index.js"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid prefix target",
+			data:    `{"imports": {"lib/": "/lib/index.js"}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gomonkey.NewFSModuleLoader(fstest.MapFS{}, gomonkey.WithImportMap([]byte(tt.data)))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewFSModuleLoader() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFSModuleLoaderResolve(t *testing.T) {
+	loader, err := gomonkey.NewFSModuleLoader(fstest.MapFS{}, gomonkey.WithImportMap([]byte(`{
+  "imports": {
+    "lib": "/vendor/lib/index.js",
+    "lib/": "/vendor/lib/",
+    "/app/config.js": "/app/config.prod.js"
+  },
+  "scopes": {
+    "/vendor/": {
+      "lib": "/vendor/lib/legacy.js"
+    }
+  }
+}`)))
+	if err != nil {
+		t.Fatal()
+	}
+
+	tests := []struct {
+		name      string
+		referrer  string
+		specifier string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "relative",
+			referrer:  "app/main.js",
+			specifier: "./utils.js",
+			want:      "app/utils.js",
+		},
+		{
+			name:      "parent",
+			referrer:  "app/pages/index.js",
+			specifier: "../utils.js",
+			want:      "app/utils.js",
+		},
+		{
+			name:      "absolute",
+			referrer:  "app/main.js",
+			specifier: "/shared/utils.js",
+			want:      "shared/utils.js",
+		},
+		{
+			name:      "mapped bare",
+			referrer:  "app/main.js",
+			specifier: "lib",
+			want:      "vendor/lib/index.js",
+		},
+		{
+			name:      "mapped prefix",
+			referrer:  "app/main.js",
+			specifier: "lib/array.js",
+			want:      "vendor/lib/array.js",
+		},
+		{
+			name:      "mapped path",
+			referrer:  "app/main.js",
+			specifier: "./config.js",
+			want:      "app/config.prod.js",
+		},
+		{
+			name:      "scoped",
+			referrer:  "vendor/other/index.js",
+			specifier: "lib",
+			want:      "vendor/lib/legacy.js",
+		},
+		{
+			name:      "unmapped bare",
+			referrer:  "app/main.js",
+			specifier: "unknown",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loader.Resolve(tt.referrer, tt.specifier)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loader.Resolve() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("loader.Resolve() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFSModuleLoaderLoad(t *testing.T) {
+	loader, err := gomonkey.NewFSModuleLoader(fstest.MapFS{
+		"app/main.js": &fstest.MapFile{Data: []byte(`export default 42;`)},
+	})
+	if err != nil {
+		t.Fatal()
+	}
+
+	code, err := loader.Load("app/main.js")
+	if err != nil {
+		t.Errorf("loader.Load() err = %v, want %v", err, nil)
+	}
+	if string(code) != `export default 42;` {
+		t.Errorf("loader.Load() = %s", code)
+	}
+	if _, err := loader.Load("app/unknown.js"); err == nil {
+		t.Errorf("loader.Load() err = %v, want error", err)
+	}
+}
+
+func TestFSModuleLoader_Import(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	loader, err := gomonkey.NewFSModuleLoader(fstest.MapFS{
+		"app/utils.js":   &fstest.MapFile{Data: []byte(`import { count } from "lib"; export const double = (v) => v * count();`)},
+		"app/other.js":   &fstest.MapFile{Data: []byte(`import { count } from "lib"; export const triple = (v) => v * 3; count();`)},
+		"lib/counter.js": &fstest.MapFile{Data: []byte(`let n = 0; export const count = () => ++n;`)},
+	}, gomonkey.WithImportMap([]byte(`{"imports": {"lib": "/lib/counter.js"}}`)))
+	if err != nil {
+		t.Fatal()
+	}
+	ctx, err := gomonkey.NewContext(gomonkey.WithModuleLoader(loader))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("app/main.js", []byte(`
+import { triple } from "./other.js";
+import { double } from "./utils.js";
+export default double(21);
+`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+
+	if err := module.Link(); err != nil {
+		t.Errorf("module.Link() err = %v, want %v", err, nil)
+	}
+	promise, err := module.Evaluate()
+	if err != nil {
+		t.Fatal()
+	}
+	defer promise.Release()
+	ns, err := module.Namespace()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ns.Release()
+	value, err := ns.Get("default")
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	if !value.IsInt32() || value.ToInt32() != 42 {
+		t.Errorf("value = %v, want %d", value, 42)
+	}
+}
+
+func TestFSModuleLoader_ImportSyntaxError(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	loader, err := gomonkey.NewFSModuleLoader(fstest.MapFS{
+		"app/broken.js": &fstest.MapFile{Data: []byte("export const a = 1;\nexport const b = a +;")},
+	})
+	if err != nil {
+		t.Fatal()
+	}
+	ctx, err := gomonkey.NewContext(gomonkey.WithModuleLoader(loader))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("app/main.js", []byte(`import { b } from "./broken.js";`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+
+	err = module.Link()
+	if !errors.Is(err, gomonkey.ErrSyntax) {
+		t.Fatalf("module.Link() err = %v, want %v", err, gomonkey.ErrSyntax)
+	}
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) || jsErr.Name != "SyntaxError" {
+		t.Errorf("module.Link() err = %v, want SyntaxError", err)
+	}
+	var compileErr *gomonkey.JSError
+	if !errors.As(errors.Unwrap(err), &compileErr) {
+		t.Fatalf("errors.Unwrap() = %v, want *gomonkey.JSError", errors.Unwrap(err))
+	}
+	if compileErr.Filename != "app/broken.js" || compileErr.LineNumber != 2 {
+		t.Errorf("compile err location = %s:%d, want %s:%d", compileErr.Filename, compileErr.LineNumber,
+			"app/broken.js", 2)
+	}
+}
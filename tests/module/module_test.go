@@ -0,0 +1,402 @@
+package gomonkey_test_module
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+func TestModuleRelease(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("module.js", []byte(`export default 42;`))
+	if err != nil {
+		t.Fatal()
+	}
+
+	module.Release()
+}
+
+func TestModuleLink(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("module.js", []byte(`export default 42;`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+
+	if err := module.Link(); err != nil {
+		t.Errorf("module.Link() err = %v, want %v", err, nil)
+	}
+}
+
+func TestModuleLink_NoModuleLoader(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("module.js", []byte(`import { test } from "./test.js";`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+
+	if err := module.Link(); err == nil {
+		t.Errorf("module.Link() err = %v, want error", err)
+	}
+}
+
+func TestModuleEvaluate(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("module.js", []byte(`export const test = "test";`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+	if err := module.Link(); err != nil {
+		t.Fatal()
+	}
+
+	promise, err := module.Evaluate()
+	if err != nil {
+		t.Errorf("module.Evaluate() err = %v, want %v", err, nil)
+	}
+	defer promise.Release()
+	if got, err := promise.State(); err != nil || got != gomonkey.PromiseStateFulfilled {
+		t.Errorf("promise.State() = %v, %v, want %v", got, err, gomonkey.PromiseStateFulfilled)
+	}
+}
+
+func TestModuleEvaluate_Error(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("module.js", []byte(`throw new Error("test");`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+	if err := module.Link(); err != nil {
+		t.Fatal()
+	}
+
+	promise, err := module.Evaluate()
+	if err == nil {
+		promise.Release()
+		t.Errorf("module.Evaluate() err = %v, want error", err)
+	}
+	if _, ok := err.(*gomonkey.JSError); !ok {
+		t.Errorf("module.Evaluate() error type = %T, want *gomonkey.JSError{}", err)
+	}
+}
+
+func TestModuleEvaluate_TopLevelAwait(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("module.js", []byte(`
+export const test = await Promise.resolve("test");
+`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+	if err := module.Link(); err != nil {
+		t.Fatal()
+	}
+
+	promise, err := module.Evaluate()
+	if err != nil {
+		t.Fatal()
+	}
+	defer promise.Release()
+	if err := ctx.RunJobs(); err != nil {
+		t.Fatal()
+	}
+	if got, err := promise.State(); err != nil || got != gomonkey.PromiseStateFulfilled {
+		t.Errorf("promise.State() = %v, %v, want %v", got, err, gomonkey.PromiseStateFulfilled)
+	}
+	ns, err := module.Namespace()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ns.Release()
+	value, err := ns.Get("test")
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	if !value.IsString() || value.ToString() != "test" {
+		t.Fatal()
+	}
+}
+
+func TestModuleNamespace(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("module.js", []byte(`export default 42;`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+	if err := module.Link(); err != nil {
+		t.Fatal()
+	}
+	promise, err := module.Evaluate()
+	if err != nil {
+		t.Fatal()
+	}
+	defer promise.Release()
+
+	ns, err := module.Namespace()
+	if err != nil {
+		t.Errorf("module.Namespace() err = %v, want %v", err, nil)
+	}
+	defer ns.Release()
+	value, err := ns.Get("default")
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	if !value.IsInt32() || value.ToInt32() != 42 {
+		t.Fatal()
+	}
+}
+
+func TestDynamicImport_ModuleLoader(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	loader, err := gomonkey.NewFSModuleLoader(fstest.MapFS{
+		"routes/home.js": &fstest.MapFile{Data: []byte(`export default "home";`)},
+	})
+	if err != nil {
+		t.Fatal()
+	}
+	ctx, err := gomonkey.NewContext(gomonkey.WithModuleLoader(loader))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	script, err := ctx.CompileScript("main.js", []byte(`import("./routes/home.js").then((m) => m.default)`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer script.Release()
+	value, err := ctx.ExecuteScript(script)
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	promise, err := value.AsPromise()
+	if err != nil {
+		t.Fatal()
+	}
+
+	if err := ctx.RunJobs(); err != nil {
+		t.Fatal()
+	}
+	if got, err := promise.State(); err != nil || got != gomonkey.PromiseStateFulfilled {
+		t.Errorf("promise.State() = %v, %v, want %v", got, err, gomonkey.PromiseStateFulfilled)
+	}
+	result, err := promise.Result()
+	if err != nil {
+		t.Fatal()
+	}
+	defer result.Release()
+	if !result.IsString() || result.ToString() != "home" {
+		t.Errorf("result = %v, want %s", result, "home")
+	}
+}
+
+func TestDynamicImport_Callback(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var imports []*gomonkey.DynamicImport
+	ctx, err := gomonkey.NewContext(gomonkey.WithDynamicImport(func(ctx *gomonkey.Context, imp *gomonkey.DynamicImport) {
+		imports = append(imports, imp)
+	}))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	value, err := ctx.Evaluate([]byte(`Promise.all([import("lazy"), import("missing").catch((e) => e.message)])`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	promise, err := value.AsPromise()
+	if err != nil {
+		t.Fatal()
+	}
+	if len(imports) != 2 || imports[0].Specifier != "lazy" || imports[1].Specifier != "missing" {
+		t.Fatal()
+	}
+	module, err := ctx.CompileModule("lazy.js", []byte(`export const name = "lazy";`))
+	if err != nil {
+		t.Fatal()
+	}
+
+	if err := imports[0].Finish(module); err != nil {
+		t.Errorf("imp.Finish() err = %v, want %v", err, nil)
+	}
+	if err := imports[1].Fail(errors.New("not found")); err != nil {
+		t.Errorf("imp.Fail() err = %v, want %v", err, nil)
+	}
+	if err := imports[1].Fail(errors.New("not found")); err == nil {
+		t.Errorf("imp.Fail() err = %v, want error", err)
+	}
+	if err := ctx.RunJobs(); err != nil {
+		t.Fatal()
+	}
+	if got, err := promise.State(); err != nil || got != gomonkey.PromiseStateFulfilled {
+		t.Fatalf("promise.State() = %v, %v, want %v", got, err, gomonkey.PromiseStateFulfilled)
+	}
+	result, err := promise.Result()
+	if err != nil {
+		t.Fatal()
+	}
+	defer result.Release()
+	data, err := gomonkey.JSONStringify(ctx, result)
+	if err != nil {
+		t.Fatal()
+	}
+	if data != `[{"name":"lazy"},"not found"]` {
+		t.Errorf("result = %s", data)
+	}
+}
+
+func TestModuleMetadata(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext(gomonkey.WithModuleMetadata(func(ctx *gomonkey.Context, name string,
+		meta *gomonkey.Object) error {
+		url, err := gomonkey.NewValueString(ctx, "file:///"+name)
+		if err != nil {
+			return err
+		}
+		defer url.Release()
+		return meta.Set("url", url)
+	}))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.CompileModule("module.js", []byte(`export default import.meta.url;`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer module.Release()
+	if err := module.Link(); err != nil {
+		t.Fatal()
+	}
+	promise, err := module.Evaluate()
+	if err != nil {
+		t.Fatal()
+	}
+	defer promise.Release()
+
+	ns, err := module.Namespace()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ns.Release()
+	value, err := ns.Get("default")
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	if !value.IsString() || value.ToString() != "file:///module.js" {
+		t.Errorf("value = %v, want %s", value, "file:///module.js")
+	}
+}
+
+func TestDynamicImport_Destroy(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var imports []*gomonkey.DynamicImport
+	ctx, err := gomonkey.NewContext(gomonkey.WithDynamicImport(func(ctx *gomonkey.Context, imp *gomonkey.DynamicImport) {
+		imports = append(imports, imp)
+	}))
+	if err != nil {
+		t.Fatal()
+	}
+	value, err := ctx.Evaluate([]byte(`import("a"); import("b"); import("pending");`))
+	if err != nil {
+		t.Fatal()
+	}
+	value.Release()
+	if len(imports) != 3 {
+		t.Fatal()
+	}
+	module, err := ctx.CompileModule("shared.js", []byte(`export const name = "shared";`))
+	if err != nil {
+		t.Fatal()
+	}
+
+	// the module is owned once by the context and the pending import is released with it
+	if err := imports[0].Finish(module); err != nil {
+		t.Errorf("imp.Finish() err = %v, want %v", err, nil)
+	}
+	if err := imports[1].Finish(module); err != nil {
+		t.Errorf("imp.Finish() err = %v, want %v", err, nil)
+	}
+	ctx.Destroy()
+	if err := imports[2].Fail(errors.New("not found")); err == nil {
+		t.Errorf("imp.Fail() err = %v, want error", err)
+	}
+}
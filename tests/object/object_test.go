@@ -33,6 +33,118 @@ func TestNewObject(t *testing.T) {
 	object.Release()
 }
 
+func TestNewHostObject(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	object, err := gomonkey.NewHostObject(ctx, "test")
+	if err != nil {
+		t.Errorf("NewHostObject() err = %v, want %v", err, nil)
+	}
+	object.Release()
+}
+
+type hostData struct {
+	finalized bool
+}
+
+func (d *hostData) Finalize() {
+	d.finalized = true
+}
+
+func TestNewHostObject_Finalize(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	data := &hostData{}
+	object, err := gomonkey.NewHostObject(ctx, data)
+	if err != nil {
+		t.Fatal()
+	}
+	object.Release()
+	ctx.Destroy()
+
+	if !data.finalized {
+		t.Errorf("data.finalized = %v, want %v", data.finalized, true)
+	}
+}
+
+func TestObjectHostData(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+	object, err := gomonkey.NewHostObject(ctx, "test")
+	if err != nil {
+		t.Fatal()
+	}
+	defer object.Release()
+	if err := global.Set("host", object.AsValue()); err != nil {
+		t.Fatal()
+	}
+
+	data, err := object.HostData()
+	if err != nil {
+		t.Errorf("object.HostData() err = %v, want %v", err, nil)
+	}
+	if data != "test" {
+		t.Errorf("object.HostData() = %v, want %v", data, "test")
+	}
+
+	result, err := ctx.Evaluate([]byte(`host`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer result.Release()
+	host, err := result.AsObject()
+	if err != nil {
+		t.Fatal()
+	}
+	data, err = host.HostData()
+	if err != nil || data != "test" {
+		t.Errorf("host.HostData() = %v, %v, want %v, %v", data, err, "test", nil)
+	}
+}
+
+func TestObjectHostData_PlainObject(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	object, err := gomonkey.NewObject(ctx)
+	if err != nil {
+		t.Fatal()
+	}
+	defer object.Release()
+
+	if _, err := object.HostData(); err == nil {
+		t.Errorf("object.HostData() err = %v, want error", err)
+	}
+}
+
 func TestObjectRelease(t *testing.T) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
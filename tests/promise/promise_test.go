@@ -0,0 +1,287 @@
+package gomonkey_test_promise
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+func TestNewPromise(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	promise, resolver, err := gomonkey.NewPromise(ctx)
+	if err != nil {
+		t.Errorf("NewPromise() err = %v, want %v", err, nil)
+	}
+	defer promise.Release()
+	defer resolver.Release()
+	if got, err := promise.State(); err != nil || got != gomonkey.PromiseStatePending {
+		t.Errorf("promise.State() = %v, %v, want %v", got, err, gomonkey.PromiseStatePending)
+	}
+}
+
+func TestPromiseRelease(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	value, err := ctx.Evaluate([]byte(`Promise.resolve(42)`))
+	if err != nil {
+		t.Fatal()
+	}
+	promise, err := value.AsPromise()
+	if err != nil {
+		t.Fatal()
+	}
+
+	promise.Release()
+}
+
+func TestPromiseState(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+
+	tests := []struct {
+		name string
+		code string
+		want gomonkey.PromiseState
+	}{
+		{
+			name: "pending",
+			code: `new Promise(() => {})`,
+			want: gomonkey.PromiseStatePending,
+		},
+		{
+			name: "fulfilled",
+			code: `Promise.resolve(42)`,
+			want: gomonkey.PromiseStateFulfilled,
+		},
+		{
+			name: "rejected",
+			code: `Promise.reject(new Error("test"))`,
+			want: gomonkey.PromiseStateRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ctx.Evaluate([]byte(tt.code))
+			if err != nil {
+				t.Fatal()
+			}
+			defer value.Release()
+			promise, err := value.AsPromise()
+			if err != nil {
+				t.Fatal()
+			}
+
+			if got, err := promise.State(); err != nil || got != tt.want {
+				t.Errorf("promise.State() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromiseResult(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	value, err := ctx.Evaluate([]byte(`(async () => { return 42; })().then((v) => v * 2)`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	promise, err := value.AsPromise()
+	if err != nil {
+		t.Fatal()
+	}
+	if _, err := promise.Result(); err == nil {
+		t.Errorf("promise.Result() err = %v, want error", err)
+	}
+	if err := ctx.RunJobs(); err != nil {
+		t.Fatal()
+	}
+
+	result, err := promise.Result()
+	if err != nil {
+		t.Errorf("promise.Result() err = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if !result.IsInt32() || result.ToInt32() != 84 {
+		t.Fatal()
+	}
+}
+
+func TestPromiseAsValue(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	value, err := ctx.Evaluate([]byte(`Promise.resolve(42)`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	promise, err := value.AsPromise()
+	if err != nil {
+		t.Fatal()
+	}
+
+	val := promise.AsValue()
+	if val == nil {
+		t.Errorf("promise.AsValue() = %v", val)
+	}
+}
+
+func TestPromiseResolverRelease(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	promise, resolver, err := gomonkey.NewPromise(ctx)
+	if err != nil {
+		t.Fatal()
+	}
+	defer promise.Release()
+
+	resolver.Release()
+}
+
+func TestPromiseResolverResolve(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+	var resolvers []*gomonkey.PromiseResolver
+	fetch := func(args []*gomonkey.Value) (*gomonkey.Value, error) {
+		promise, resolver, err := gomonkey.NewPromise(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, resolver)
+		return promise.AsValue(), nil
+	}
+	if err := ctx.DefineFunction(global, "fetch", fetch, 0, gomonkey.PropertyAttributeDefault); err != nil {
+		t.Fatal()
+	}
+	value, err := ctx.Evaluate([]byte(`(async () => { const v = await fetch(); return v * 2; })()`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	promise, err := value.AsPromise()
+	if err != nil {
+		t.Fatal()
+	}
+	if len(resolvers) != 1 {
+		t.Fatal()
+	}
+	defer resolvers[0].Release()
+	arg, err := gomonkey.NewValueInt32(ctx, 21)
+	if err != nil {
+		t.Fatal()
+	}
+	defer arg.Release()
+
+	if err := resolvers[0].Resolve(arg); err != nil {
+		t.Errorf("resolver.Resolve() err = %v, want %v", err, nil)
+	}
+	if err := ctx.RunJobs(); err != nil {
+		t.Fatal()
+	}
+	if got, err := promise.State(); err != nil || got != gomonkey.PromiseStateFulfilled {
+		t.Errorf("promise.State() = %v, %v, want %v", got, err, gomonkey.PromiseStateFulfilled)
+	}
+	result, err := promise.Result()
+	if err != nil {
+		t.Fatal()
+	}
+	defer result.Release()
+	if !result.IsInt32() || result.ToInt32() != 42 {
+		t.Fatal()
+	}
+}
+
+func TestPromiseResolverReject(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	promise, resolver, err := gomonkey.NewPromise(ctx)
+	if err != nil {
+		t.Fatal()
+	}
+	defer promise.Release()
+	defer resolver.Release()
+	reason, err := gomonkey.NewValueString(ctx, "test")
+	if err != nil {
+		t.Fatal()
+	}
+	defer reason.Release()
+
+	if err := resolver.Reject(reason); err != nil {
+		t.Errorf("resolver.Reject() err = %v, want %v", err, nil)
+	}
+	if got, err := promise.State(); err != nil || got != gomonkey.PromiseStateRejected {
+		t.Errorf("promise.State() = %v, %v, want %v", got, err, gomonkey.PromiseStateRejected)
+	}
+	result, err := promise.Result()
+	if err != nil {
+		t.Fatal()
+	}
+	defer result.Release()
+	if !result.IsString() || result.ToString() != "test" {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,204 @@
+package gomonkey_test_proxy
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+func newStoreProxy(ctx *gomonkey.Context, store map[string]string) (*gomonkey.Object, error) {
+	return gomonkey.NewProxy(ctx, nil, gomonkey.ProxyHandler{
+		Get: func(target *gomonkey.Object, key *gomonkey.Value, receiver *gomonkey.Value) (*gomonkey.Value, error) {
+			value, ok := store[key.ToString()]
+			if !key.IsString() || !ok {
+				return nil, nil
+			}
+			return gomonkey.NewValueString(ctx, value)
+		},
+		Set: func(target *gomonkey.Object, key *gomonkey.Value, value *gomonkey.Value,
+			receiver *gomonkey.Value) (bool, error) {
+			store[key.ToString()] = value.ToString()
+			return true, nil
+		},
+		Has: func(target *gomonkey.Object, key *gomonkey.Value) (bool, error) {
+			_, ok := store[key.ToString()]
+			return ok, nil
+		},
+		DeleteProperty: func(target *gomonkey.Object, key *gomonkey.Value) (bool, error) {
+			delete(store, key.ToString())
+			return true, nil
+		},
+		OwnKeys: func(target *gomonkey.Object) ([]string, error) {
+			keys := make([]string, 0, len(store))
+			for key := range store {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return keys, nil
+		},
+		GetOwnPropertyDescriptor: func(target *gomonkey.Object, key *gomonkey.Value) (*gomonkey.Value, error) {
+			if _, ok := store[key.ToString()]; !ok {
+				return nil, nil
+			}
+			return ctx.Evaluate([]byte(`({ value: "", enumerable: true, configurable: true, writable: true })`))
+		},
+	})
+}
+
+func TestNewProxy(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	store := map[string]string{"a": "1", "b": "2"}
+	proxy, err := newStoreProxy(ctx, store)
+	if err != nil {
+		t.Fatalf("NewProxy() err = %v, want %v", err, nil)
+	}
+	defer proxy.Release()
+	if err := global.Set("store", proxy.AsValue()); err != nil {
+		t.Fatal()
+	}
+
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: `store.a + store.b`, want: "12"},
+		{code: `String(store.c)`, want: "undefined"},
+		{code: `store.c = "3"; store.c`, want: "3"},
+		{code: `String("a" in store) + String("d" in store)`, want: "truefalse"},
+		{code: `delete store.a; String("a" in store)`, want: "false"},
+		{code: `Object.keys(store).join(",")`, want: "b,c"},
+	}
+	for _, tt := range tests {
+		result, err := ctx.Evaluate([]byte(tt.code))
+		if err != nil {
+			t.Errorf("ctx.Evaluate(%q) err = %v, want %v", tt.code, err, nil)
+			continue
+		}
+		if !result.IsString() || result.ToString() != tt.want {
+			t.Errorf("ctx.Evaluate(%q) = %v, want %v", tt.code, result, tt.want)
+		}
+		result.Release()
+	}
+	if _, ok := store["a"]; ok {
+		t.Errorf("store[a] exists, want deleted")
+	}
+	if store["c"] != "3" {
+		t.Errorf("store[c] = %v, want %v", store["c"], "3")
+	}
+}
+
+func TestNewProxy_Apply(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+
+	proxy, err := gomonkey.NewProxy(ctx, nil, gomonkey.ProxyHandler{
+		Apply: func(target *gomonkey.Object, this *gomonkey.Value, args []*gomonkey.Value) (*gomonkey.Value, error) {
+			var sum int32
+			for _, arg := range args {
+				sum += arg.ToInt32()
+			}
+			return gomonkey.NewValueInt32(ctx, sum)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewProxy() err = %v, want %v", err, nil)
+	}
+	defer proxy.Release()
+	if err := global.Set("sum", proxy.AsValue()); err != nil {
+		t.Fatal()
+	}
+
+	result, err := ctx.Evaluate([]byte(`sum(1, 2, 3)`))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if !result.IsInt32() || result.ToInt32() != 6 {
+		t.Errorf("result = %v, want %v", result, 6)
+	}
+}
+
+func TestNewProxy_Target(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	global, err := ctx.Global()
+	if err != nil {
+		t.Fatal()
+	}
+	defer global.Release()
+	target, err := gomonkey.NewObject(ctx)
+	if err != nil {
+		t.Fatal()
+	}
+	defer target.Release()
+	value, err := gomonkey.NewValueString(ctx, "target")
+	if err != nil {
+		t.Fatal()
+	}
+	defer value.Release()
+	if err := target.Set("name", value); err != nil {
+		t.Fatal()
+	}
+
+	proxy, err := gomonkey.NewProxy(ctx, target, gomonkey.ProxyHandler{
+		Has: func(target *gomonkey.Object, key *gomonkey.Value) (bool, error) {
+			return true, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewProxy() err = %v, want %v", err, nil)
+	}
+	defer proxy.Release()
+	if err := global.Set("proxy", proxy.AsValue()); err != nil {
+		t.Fatal()
+	}
+
+	result, err := ctx.Evaluate([]byte(`proxy.name + String("other" in proxy)`))
+	if err != nil {
+		t.Fatalf("ctx.Evaluate() err = %v, want %v", err, nil)
+	}
+	defer result.Release()
+	if !result.IsString() || result.ToString() != "targettrue" {
+		t.Errorf("result = %v, want %v", result, "targettrue")
+	}
+}
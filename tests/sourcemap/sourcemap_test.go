@@ -0,0 +1,277 @@
+package gomonkey_test_sourcemap
+
+import (
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"os"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bhuisgen/gomonkey"
+)
+
+func TestMain(m *testing.M) {
+	gomonkey.Init()
+	code := m.Run()
+	gomonkey.ShutDown()
+	os.Exit(code)
+}
+
+// bundle is the generated code of src/app.js:
+//
+//	function fail() {
+//	  throw new Error("boom");
+//	}
+//	fail();
+const bundle = `function fail(){throw new Error("boom")}
+fail();
+`
+
+const sourceMap = `{"version":3,"sources":["app.js"],"sourceRoot":"src","names":[],"mappings":"AAAA,gBACE,MAAM;AAER"}`
+
+func TestParseSourceMap(t *testing.T) {
+	m, err := gomonkey.ParseSourceMap([]byte(sourceMap))
+	if err != nil {
+		t.Fatalf("ParseSourceMap() err = %v, want %v", err, nil)
+	}
+
+	tests := []struct {
+		line   int
+		column int
+		want   gomonkey.SourcePosition
+		ok     bool
+	}{
+		{1, 1, gomonkey.SourcePosition{Source: "src/app.js", Line: 1, Column: 1}, true},
+		{1, 17, gomonkey.SourcePosition{Source: "src/app.js", Line: 2, Column: 3}, true},
+		{1, 25, gomonkey.SourcePosition{Source: "src/app.js", Line: 2, Column: 9}, true},
+		{2, 3, gomonkey.SourcePosition{Source: "src/app.js", Line: 4, Column: 1}, true},
+		{3, 1, gomonkey.SourcePosition{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := m.Lookup(tt.line, tt.column)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("m.Lookup(%d, %d) = %v, %t, want %v, %t", tt.line, tt.column, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	for _, data := range []string{`{`, `{"version":2}`, `{"version":3,"sources":[],"mappings":"AAAA"}`,
+		`{"version":3,"sources":["a.js"],"mappings":"A!"}`} {
+		if _, err := gomonkey.ParseSourceMap([]byte(data)); err == nil {
+			t.Errorf("ParseSourceMap(%q) err = %v, want an error", data, err)
+		}
+	}
+}
+
+func checkError(t *testing.T, err error) {
+	t.Helper()
+	var jsErr *gomonkey.JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("err type = %T, want *gomonkey.JSError", err)
+	}
+	defer jsErr.Release()
+	if jsErr.Filename != "src/app.js" || jsErr.LineNumber != 2 {
+		t.Errorf("jsErr location = %s:%d, want %s:%d", jsErr.Filename, jsErr.LineNumber, "src/app.js", 2)
+	}
+	if len(jsErr.Stack) < 2 {
+		t.Fatalf("jsErr.Stack = %v, want 2 frames", jsErr.Stack)
+	}
+	if frame := jsErr.Stack[0]; frame.Filename != "src/app.js" || frame.LineNumber != 2 {
+		t.Errorf("jsErr.Stack[0] = %v, want %s:%d", frame, "src/app.js", 2)
+	}
+	if frame := jsErr.Stack[1]; frame.Filename != "src/app.js" || frame.LineNumber != 4 {
+		t.Errorf("jsErr.Stack[1] = %v, want %s:%d", frame, "src/app.js", 4)
+	}
+}
+
+func executeBundle(t *testing.T, ctx *gomonkey.Context, code string) error {
+	t.Helper()
+	script, err := ctx.CompileScript("dist/bundle.js", []byte(code))
+	if err != nil {
+		t.Fatalf("ctx.CompileScript() err = %v, want %v", err, nil)
+	}
+	defer script.Release()
+	value, err := ctx.ExecuteScript(script)
+	if err == nil {
+		value.Release()
+		t.Fatalf("ctx.ExecuteScript() err = %v, want an error", err)
+	}
+	return err
+}
+
+func TestContextRegisterSourceMap(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	m, err := gomonkey.ParseSourceMap([]byte(sourceMap))
+	if err != nil {
+		t.Fatal()
+	}
+	ctx.RegisterSourceMap("dist/bundle.js", m)
+
+	checkError(t, executeBundle(t, ctx, bundle))
+}
+
+func TestRegisterSourceMap(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	m, err := gomonkey.ParseSourceMap([]byte(sourceMap))
+	if err != nil {
+		t.Fatal()
+	}
+	gomonkey.RegisterSourceMap("dist/bundle.js", m)
+	defer gomonkey.UnregisterSourceMap("dist/bundle.js")
+
+	checkError(t, executeBundle(t, ctx, bundle))
+}
+
+func TestNewContext_WithSourceMaps(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	tests := []struct {
+		name string
+		fsys fs.FS
+		code string
+	}{
+		{
+			name: "inline",
+			code: bundle + "//# sourceMappingURL=data:application/json;base64," +
+				base64.StdEncoding.EncodeToString([]byte(sourceMap)) + "\n",
+		},
+		{
+			name: "external",
+			fsys: fstest.MapFS{"dist/bundle.js.map": {Data: []byte(sourceMap)}},
+			code: bundle + "//# sourceMappingURL=bundle.js.map\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := gomonkey.NewContext(gomonkey.WithSourceMaps(tt.fsys))
+			if err != nil {
+				t.Fatal()
+			}
+			defer ctx.Destroy()
+
+			checkError(t, executeBundle(t, ctx, tt.code))
+		})
+	}
+}
+
+func TestContextUnregisterSourceMap(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	m, err := gomonkey.ParseSourceMap([]byte(sourceMap))
+	if err != nil {
+		t.Fatal()
+	}
+	ctx.RegisterSourceMap("dist/bundle.js", m)
+	ctx.UnregisterSourceMap("dist/bundle.js")
+
+	var jsErr *gomonkey.JSError
+	if err := executeBundle(t, ctx, bundle); !errors.As(err, &jsErr) {
+		t.Fatalf("err type = %T, want *gomonkey.JSError", err)
+	}
+	if jsErr.Filename != "dist/bundle.js" || jsErr.LineNumber != 1 {
+		t.Errorf("jsErr location = %s:%d, want %s:%d", jsErr.Filename, jsErr.LineNumber, "dist/bundle.js", 1)
+	}
+}
+
+func TestNewFrontendContext_WithSourceMaps(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	fc, err := gomonkey.NewFrontendContext(gomonkey.WithFrontendSourceMaps(nil))
+	if err != nil {
+		t.Fatal()
+	}
+	defer fc.Destroy()
+	code := bundle + "//# sourceMappingURL=data:application/json;base64," +
+		base64.StdEncoding.EncodeToString([]byte(sourceMap)) + "\n"
+	stencil, err := fc.CompileScriptToStencil("dist/bundle.js", []byte(code))
+	if err != nil {
+		t.Fatalf("fc.CompileScriptToStencil() err = %v, want %v", err, nil)
+	}
+	if stencil.SourceMap() == nil {
+		t.Errorf("stencil.SourceMap() = %v, want a source map", stencil.SourceMap())
+	}
+	data, err := stencil.MarshalBinary()
+	stencil.Release()
+	if err != nil {
+		t.Fatal()
+	}
+	decoded, err := gomonkey.UnmarshalStencil(data)
+	if err != nil {
+		t.Fatalf("UnmarshalStencil() err = %v, want %v", err, nil)
+	}
+	defer decoded.Release()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	value, err := ctx.ExecuteScriptFromStencil(decoded)
+	if err == nil {
+		value.Release()
+		t.Fatalf("ctx.ExecuteScriptFromStencil() err = %v, want an error", err)
+	}
+	checkError(t, err)
+}
+
+func TestSourceMaps_Invalid(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	code := []byte(bundle + "//# sourceMappingURL=data:application/json;base64," +
+		base64.StdEncoding.EncodeToString([]byte(`{"version":2}`)) + "\n")
+
+	ctx, err := gomonkey.NewContext(gomonkey.WithSourceMaps(nil))
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	script, err := ctx.CompileScript("dist/bundle.js", code)
+	if err != nil {
+		t.Fatalf("ctx.CompileScript() err = %v, want %v", err, nil)
+	}
+	script.Release()
+	module, err := ctx.CompileModule("dist/bundle.mjs", []byte(`export const test = "test";`+"\n"+
+		"//# sourceMappingURL=data:application/json;base64,e30K\n"))
+	if err != nil {
+		t.Fatalf("ctx.CompileModule() err = %v, want %v", err, nil)
+	}
+	module.Release()
+
+	fc, err := gomonkey.NewFrontendContext(gomonkey.WithFrontendSourceMaps(nil))
+	if err != nil {
+		t.Fatal()
+	}
+	defer fc.Destroy()
+	stencil, err := fc.CompileScriptToStencil("dist/bundle.js", code)
+	if err != nil {
+		t.Fatalf("fc.CompileScriptToStencil() err = %v, want %v", err, nil)
+	}
+	defer stencil.Release()
+	if stencil.SourceMap() != nil {
+		t.Errorf("stencil.SourceMap() = %v, want %v", stencil.SourceMap(), nil)
+	}
+}
@@ -1,6 +1,8 @@
 package gomonkey_test_stencil
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"runtime"
 	"testing"
@@ -31,3 +33,158 @@ func TestStencilRelease(t *testing.T) {
 
 	stencil.Release()
 }
+
+func TestStencilMarshalBinary(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	fc, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer fc.Destroy()
+	stencil, err := fc.CompileScriptToStencil("script.js", []byte(`(() => { return "test"; })()`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer stencil.Release()
+
+	data, err := stencil.MarshalBinary()
+	if err != nil {
+		t.Fatalf("stencil.MarshalBinary() err = %v, want %v", err, nil)
+	}
+	if len(data) == 0 {
+		t.Errorf("stencil.MarshalBinary() = %v", data)
+	}
+	again, err := stencil.MarshalBinary()
+	if err != nil || !bytes.Equal(again, data) {
+		t.Errorf("stencil.MarshalBinary() = %v, %v, want %v, %v", again, err, data, nil)
+	}
+	if _, err := gomonkey.UnmarshalStencil(data[:len(data)-16]); err == nil {
+		t.Errorf("UnmarshalStencil() err = %v, want error", err)
+	}
+}
+
+func TestUnmarshalStencil(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	fc, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer fc.Destroy()
+	stencil, err := fc.CompileScriptToStencil("script.js", []byte(`(() => { return "test"; })()`))
+	if err != nil {
+		t.Fatal()
+	}
+	data, err := stencil.MarshalBinary()
+	stencil.Release()
+	if err != nil {
+		t.Fatal()
+	}
+
+	decoded, err := gomonkey.UnmarshalStencil(data)
+	if err != nil {
+		t.Fatalf("UnmarshalStencil() err = %v, want %v", err, nil)
+	}
+	defer decoded.Release()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	result, err := ctx.ExecuteScriptFromStencil(decoded)
+	if err != nil {
+		t.Fatal()
+	}
+	defer result.Release()
+	if !result.IsString() || result.ToString() != "test" {
+		t.Errorf("result = %v, want %v", result, "test")
+	}
+}
+
+func TestUnmarshalStencil_Module(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	fc, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer fc.Destroy()
+	stencil, err := fc.CompileModuleToStencil("module.js", []byte(`export const test = "test";`))
+	if err != nil {
+		t.Fatal()
+	}
+	data, err := stencil.MarshalBinary()
+	stencil.Release()
+	if err != nil {
+		t.Fatal()
+	}
+
+	decoded, err := gomonkey.UnmarshalStencil(data)
+	if err != nil {
+		t.Fatalf("UnmarshalStencil() err = %v, want %v", err, nil)
+	}
+	defer decoded.Release()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	module, err := ctx.InstantiateModuleFromStencil(decoded)
+	if err != nil {
+		t.Errorf("ctx.InstantiateModuleFromStencil() err = %v, want %v", err, nil)
+	}
+	module.Release()
+}
+
+func TestUnmarshalStencil_Invalid(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "magic", data: []byte("invalid")},
+		{name: "version", data: append([]byte("GMKS\x041.0\x00"), 0x00, 0x01)},
+		{name: "build", data: append(append([]byte("GMKS"), byte(len(gomonkey.Version()))), append([]byte(gomonkey.Version()), 0x05, 'o', 't', 'h', 'e', 'r', 0x00, 0x01)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gomonkey.UnmarshalStencil(tt.data); err == nil {
+				t.Errorf("UnmarshalStencil() err = %v, want error", err)
+			}
+		})
+	}
+}
+
+func TestCompileToStencil_Syntax(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	fc, err := gomonkey.NewFrontendContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer fc.Destroy()
+
+	if stencil, err := fc.CompileScriptToStencil("script.js", []byte("var x = ;")); !errors.Is(err, gomonkey.ErrSyntax) {
+		if err == nil {
+			stencil.Release()
+		}
+		t.Errorf("fc.CompileScriptToStencil() err = %v, want %v", err, gomonkey.ErrSyntax)
+	}
+	if stencil, err := fc.CompileModuleToStencil("module.js", []byte("export var x = ;")); !errors.Is(err,
+		gomonkey.ErrSyntax) {
+		if err == nil {
+			stencil.Release()
+		}
+		t.Errorf("fc.CompileModuleToStencil() err = %v, want %v", err, gomonkey.ErrSyntax)
+	}
+}
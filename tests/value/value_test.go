@@ -353,6 +353,30 @@ func TestValueAsFunction(t *testing.T) {
 	}
 }
 
+func TestValueAsPromise(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	v, err := ctx.Evaluate([]byte(`Promise.resolve(42)`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer v.Release()
+
+	promise, err := v.AsPromise()
+	if err != nil {
+		t.Errorf("v.AsPromise() err = %v, want %v", err, nil)
+	}
+	if promise == nil {
+		t.Errorf("v.AsPromise() = %v", promise)
+	}
+}
+
 func TestValueIs(t *testing.T) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -481,6 +505,26 @@ func TestValueIsFalse(t *testing.T) {
 	}
 }
 
+func TestValueIsPromise(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := gomonkey.NewContext()
+	if err != nil {
+		t.Fatal()
+	}
+	defer ctx.Destroy()
+	v, err := ctx.Evaluate([]byte(`Promise.resolve(42)`))
+	if err != nil {
+		t.Fatal()
+	}
+	defer v.Release()
+
+	if got := v.IsPromise(); got != true {
+		t.Errorf("v.IsPromise() got %v, want %v", got, true)
+	}
+}
+
 func TestValueIsString(t *testing.T) {
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -65,6 +65,36 @@ func (v *Value) Release() {
 	C.ReleaseValue(v.ptr)
 }
 
+// clone returns a new reference to the same JS value.
+func (v *Value) clone() (*Value, error) {
+	result := C.CopyValue(v.ptr)
+	return valueFromResult(v.ctx, result)
+}
+
+// typeOf returns the JS type of the value.
+func (v *Value) typeOf() string {
+	switch {
+	case v.IsUndefined():
+		return "undefined"
+	case v.IsNull():
+		return "null"
+	case v.IsBoolean():
+		return "boolean"
+	case v.IsNumber():
+		return "number"
+	case v.IsString():
+		return "string"
+	case v.IsSymbol():
+		return "symbol"
+	case v.IsFunction():
+		return "function"
+	case v.IsObject():
+		return "object"
+	default:
+		return "bigint"
+	}
+}
+
 // String returns the value string representation.
 func (v *Value) String() string {
 	cStr := C.ToString(v.ptr)
@@ -115,6 +145,14 @@ func (v *Value) AsFunction() (*Function, error) {
 	return &Function{v}, nil
 }
 
+// AsPromise casts as a JS promise.
+func (v *Value) AsPromise() (*Promise, error) {
+	if !v.IsPromise() {
+		return nil, errors.New("not a JS::Promise")
+	}
+	return &Promise{v}, nil
+}
+
 // Is checks if the JS value is the same.
 func (v *Value) Is(value *Value) bool {
 	return bool(C.ValueIs(v.ptr, value.ptr))
@@ -155,6 +193,11 @@ func (v *Value) IsFunction() bool {
 	return bool(C.ValueIsFunction(v.ptr))
 }
 
+// IsPromise checks if the JS value is a JS promise.
+func (v *Value) IsPromise() bool {
+	return bool(C.ValueIsPromise(v.ptr))
+}
+
 // IsSymbol checks if the JS value is a JS symbol.
 func (v *Value) IsSymbol() bool {
 	return bool(C.ValueIsSymbol(v.ptr))